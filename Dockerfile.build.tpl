@@ -18,11 +18,13 @@ RUN mkdir -p /opt/driver/src && \
 # As minimal build tools you need: make, curl and git, install using the same
 # command the specific tools required to build the driver.
 RUN apk add --no-cache make git curl ca-certificates
-RUN curl https://storage.googleapis.com/golang/go1.9.2.linux-amd64.tar.gz -O && \
-    tar -C /usr/local -xzf go1.9.2.linux-amd64.tar.gz
+RUN curl https://storage.googleapis.com/golang/go1.22.5.linux-amd64.tar.gz -O && \
+    tar -C /usr/local -xzf go1.22.5.linux-amd64.tar.gz
 
 ENV PATH="/usr/local/go/bin:${PATH}"
 ENV GOPATH="/opt/driver"
+# The native component is built from GOPATH with its vendored dependencies.
+ENV GO111MODULE="off"
 ENV CONTAINER="yes"
 
 # A nasty hack to make the Go binary work on alpine.
@@ -5,15 +5,16 @@ import (
 	. "gopkg.in/bblfsh/sdk.v1/uast/ann"
 	"gopkg.in/bblfsh/sdk.v1/uast/transformer"
 	"gopkg.in/bblfsh/sdk.v1/uast/transformer/annotatter"
-	"gopkg.in/bblfsh/sdk.v1/uast/transformer/positioner"
 )
 
 // Transformers is the of list `transformer.Transfomer` to apply to a UAST, to
 // learn more about the Transformers and the available ones take a look to:
 // https://godoc.org/gopkg.in/bblfsh/sdk.v1/uast/transformers
+//
+// Lines and columns come from the native AST, so they're not filled from the
+// offsets.
 var Transformers = []transformer.Tranformer{
 	annotatter.NewAnnotatter(AnnotationRules),
-	positioner.NewFillLineColFromOffset(),
 }
 
 // AnnotationRules describes how a UAST should be annotated with `uast.Role`.
@@ -21,4 +22,104 @@ var Transformers = []transformer.Tranformer{
 // https://godoc.org/gopkg.in/bblfsh/sdk.v1/uast/ann
 var AnnotationRules = On(Any).Roles(uast.File).Descendants(
 	On(HasInternalType("Ident")).Roles(uast.Identifier),
+
+	// Bad nodes mark the code the parser could not make sense of.
+	On(Or(
+		HasInternalType("BadExpr"),
+		HasInternalType("BadStmt"),
+		HasInternalType("BadDecl"),
+		HasInternalType("BadDirective"),
+	)).Roles(uast.Incomplete),
+
+	// Type parameters of generic functions and types, each of them declared
+	// along with the constraint it must implement.
+	On(isTypeParams).Roles(uast.Type, uast.Declaration, uast.List).Children(
+		On(HasInternalType("ListOfField")).Children(
+			On(HasInternalType("Field")).Roles(uast.Type, uast.Declaration, uast.Argument).Children(
+				On(HasProperty("InternalName", "Names")).Children(
+					On(HasInternalType("Ident")).Roles(uast.Type, uast.Name),
+				),
+				On(HasProperty("InternalName", "Type")).Roles(uast.Type, uast.Implements),
+			),
+		),
+	),
+
+	On(HasInternalType("InterfaceType")).Descendants(typeSets...),
+	On(isTypeParams).Descendants(typeSets...),
+
+	// Instantiations of generic types and functions with several type
+	// arguments.
+	On(HasInternalType("IndexListExpr")).Roles(uast.Type, uast.Instance),
+
+	// Predeclared constraints.
+	On(And(HasInternalType("Ident"), Or(HasProperty("Name", "any"), HasProperty("Name", "comparable")))).Roles(uast.Type, uast.Primitive),
+
+	// Doc fields hold the documentation of the declaration, spec or field
+	// they belong to, any other comment group is a plain comment.
+	On(And(HasInternalType("CommentGroup"), isDoc)).Roles(uast.Documentation, uast.Comment).Descendants(
+		On(HasInternalType("Comment")).Roles(uast.Documentation, uast.Comment),
+	),
+	On(And(HasInternalType("CommentGroup"), Not(isDoc))).Roles(uast.Comment).Descendants(
+		On(HasInternalType("Comment")).Roles(uast.Comment),
+	),
+
+	// Directives, such as //go:embed, annotate what they attach to.
+	On(HasInternalType("Directive")).Roles(uast.Annotation).Children(
+		On(HasInternalType("ListOfDirectiveArg")).Children(
+			On(HasInternalType("DirectiveArg")).Roles(uast.Argument),
+		),
+	),
+
+	// The keys of struct tags, each with its value, which may hold options.
+	On(HasInternalType("StructTagKey")).Roles(uast.Entry).Children(
+		On(HasProperty("InternalName", "Key")).Roles(uast.Key),
+		On(HasProperty("InternalName", "Value")).Roles(uast.Value),
+		On(HasInternalType("ListOfStructTagOption")).Children(
+			On(HasInternalType("StructTagOption")).Roles(uast.Value),
+		),
+	),
+
+	// The preamble of import "C" holds C code, and the #cgo directives
+	// telling how to build it.
+	On(HasInternalType("CgoPreamble")).Roles(uast.Comment).Descendants(
+		On(HasInternalType("CgoDirective")).Roles(uast.Annotation),
+		On(HasInternalType("CgoArg")).Roles(uast.Argument),
+	),
+
+	// The directives of go.mod and go.work files, and the lines of go.sum
+	// files: the module they declare, and the ones they import or replace.
+	On(HasInternalType("Module")).Roles(uast.Module, uast.Declaration).Children(
+		On(HasInternalType("Path")).Roles(uast.Module, uast.Pathname, uast.Name),
+	),
+	On(Or(HasInternalType("Require"), HasInternalType("Tool"))).Roles(uast.Import).Children(
+		On(HasInternalType("Path")).Roles(uast.Import, uast.Pathname),
+	),
+	On(HasInternalType("Exclude")).Roles(uast.Import, uast.Not).Children(
+		On(HasInternalType("Path")).Roles(uast.Import, uast.Pathname),
+	),
+	On(HasInternalType("Replace")).Roles(uast.Import, uast.Alias).Children(
+		On(HasProperty("InternalName", "Old")).Roles(uast.Import, uast.Pathname),
+		On(HasProperty("InternalName", "New")).Roles(uast.Import, uast.Pathname, uast.Alias),
+	),
+	On(HasInternalType("Use")).Roles(uast.Import, uast.Module).Children(
+		On(HasInternalType("Path")).Roles(uast.Pathname),
+	),
+	On(HasInternalType("Checksum")).Children(
+		On(HasInternalType("Path")).Roles(uast.Pathname),
+	),
+	On(And(HasInternalType("ListOfComment"), HasProperty("InternalName", "Comments"))).Children(
+		On(HasInternalType("Comment")).Roles(uast.Comment),
+	),
+)
+
+// typeSets annotates the type sets in constraints: ~T stands for any type with
+// T as its underlying type, and A | B for the union of both.
+var typeSets = []*Rule{
+	On(And(HasInternalType("UnaryExpr"), HasProperty("Op", "~"))).Roles(uast.Type, uast.Base),
+	On(And(HasInternalType("BinaryExpr"), HasProperty("Op", "|"))).Roles(uast.Type, uast.Or),
+}
+
+var (
+	isDoc        = HasProperty("InternalName", "Doc")
+	isTypeParams = And(HasInternalType("FieldList"), HasProperty("InternalName", "TypeParams"))
 )
@@ -39,8 +39,8 @@ func TestTransformers(t *testing.T) {
 						},
 					},
 				},
-				StartPosition: &uast.Position{Offset: 0},
-				EndPosition:   &uast.Position{Offset: 12},
+				StartPosition: &uast.Position{Offset: 0, Line: 1, Col: 1},
+				EndPosition:   &uast.Position{Offset: 12, Line: 1, Col: 13},
 			},
 			out: &uast.Node{
 				Roles:        []uast.Role{uast.File},
@@ -92,8 +92,8 @@ func TestTransformers(t *testing.T) {
 									"Name":         "a",
 									"internalRole": "Children",
 								},
-								StartPosition: &uast.Position{Offset: 6},
-								EndPosition:   &uast.Position{Offset: 7},
+								StartPosition: &uast.Position{Offset: 6, Line: 1, Col: 7},
+								EndPosition:   &uast.Position{Offset: 7, Line: 1, Col: 8},
 							}},
 						}, {
 							InternalType: "ListOfExpr",
@@ -115,8 +115,8 @@ func TestTransformers(t *testing.T) {
 										"internalRole": "Children",
 										"InternalName": "X",
 									},
-									StartPosition: &uast.Position{Offset: 10},
-									EndPosition:   &uast.Position{Offset: 11},
+									StartPosition: &uast.Position{Offset: 10, Line: 1, Col: 11},
+									EndPosition:   &uast.Position{Offset: 11, Line: 1, Col: 12},
 								}, {
 									InternalType: "BinaryExpr",
 									Properties: map[string]string{
@@ -132,8 +132,8 @@ func TestTransformers(t *testing.T) {
 											"InternalName": "X",
 											"Kind":         "INT",
 										},
-										StartPosition: &uast.Position{Offset: 14},
-										EndPosition:   &uast.Position{Offset: 15},
+										StartPosition: &uast.Position{Offset: 14, Line: 1, Col: 15},
+										EndPosition:   &uast.Position{Offset: 15, Line: 1, Col: 16},
 									}, {
 										InternalType: "BasicLit",
 										Properties: map[string]string{
@@ -142,22 +142,22 @@ func TestTransformers(t *testing.T) {
 											"Value":        "10",
 											"internalRole": "Children",
 										},
-										StartPosition: &uast.Position{Offset: 18},
-										EndPosition:   &uast.Position{Offset: 20},
+										StartPosition: &uast.Position{Offset: 18, Line: 1, Col: 19},
+										EndPosition:   &uast.Position{Offset: 20, Line: 1, Col: 21},
 									}},
-									StartPosition: &uast.Position{Offset: 14},
-									EndPosition:   &uast.Position{Offset: 20},
+									StartPosition: &uast.Position{Offset: 14, Line: 1, Col: 15},
+									EndPosition:   &uast.Position{Offset: 20, Line: 1, Col: 21},
 								}},
-								StartPosition: &uast.Position{Offset: 10},
-								EndPosition:   &uast.Position{Offset: 20},
+								StartPosition: &uast.Position{Offset: 10, Line: 1, Col: 11},
+								EndPosition:   &uast.Position{Offset: 20, Line: 1, Col: 21},
 							}},
 						}},
-						StartPosition: &uast.Position{Offset: 6},
-						EndPosition:   &uast.Position{Offset: 20},
+						StartPosition: &uast.Position{Offset: 6, Line: 1, Col: 7},
+						EndPosition:   &uast.Position{Offset: 20, Line: 1, Col: 21},
 					}},
 				}},
-				StartPosition: &uast.Position{Offset: 0},
-				EndPosition:   &uast.Position{Offset: 20},
+				StartPosition: &uast.Position{Offset: 0, Line: 1, Col: 1},
+				EndPosition:   &uast.Position{Offset: 20, Line: 1, Col: 21},
 			},
 			out: &uast.Node{
 				InternalType: "GenDecl",
@@ -258,6 +258,444 @@ func TestTransformers(t *testing.T) {
 				EndPosition:   &uast.Position{Offset: 20, Line: 1, Col: 21},
 			},
 		},
+		{
+			name: "comments",
+			code: "",
+			in: &uast.Node{
+				InternalType: "File",
+				Children: []*uast.Node{{
+					InternalType: "CommentGroup",
+					Properties:   map[string]string{"InternalName": "Doc", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfComment",
+						Properties:   map[string]string{"InternalName": "List", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "Comment",
+							Properties:   map[string]string{"Text": "// Package p.", "internalRole": "Children"},
+						}},
+					}},
+				}, {
+					InternalType: "ListOfCommentGroup",
+					Properties:   map[string]string{"InternalName": "Comments", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "CommentGroup",
+						Properties:   map[string]string{"internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "ListOfComment",
+							Properties:   map[string]string{"InternalName": "List", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "Comment",
+								Properties:   map[string]string{"Text": "// free", "internalRole": "Children"},
+							}},
+						}},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "File",
+				Roles:        []uast.Role{uast.File},
+				Children: []*uast.Node{{
+					InternalType: "CommentGroup",
+					Roles:        []uast.Role{uast.Documentation, uast.Comment},
+					Properties:   map[string]string{"InternalName": "Doc", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfComment",
+						Properties:   map[string]string{"InternalName": "List", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "Comment",
+							Roles:        []uast.Role{uast.Documentation, uast.Comment},
+							Properties:   map[string]string{"Text": "// Package p.", "internalRole": "Children"},
+						}},
+					}},
+				}, {
+					InternalType: "ListOfCommentGroup",
+					Properties:   map[string]string{"InternalName": "Comments", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "CommentGroup",
+						Roles:        []uast.Role{uast.Comment},
+						Properties:   map[string]string{"internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "ListOfComment",
+							Properties:   map[string]string{"InternalName": "List", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "Comment",
+								Roles:        []uast.Role{uast.Comment},
+								Properties:   map[string]string{"Text": "// free", "internalRole": "Children"},
+							}},
+						}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "syntax errors",
+			code: "",
+			in: &uast.Node{
+				InternalType: "File",
+				Children: []*uast.Node{{
+					InternalType: "ListOfDecl",
+					Properties:   map[string]string{"InternalName": "Decls", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "BadDecl",
+						Properties:   map[string]string{"internalRole": "Children"},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "File",
+				Roles:        []uast.Role{uast.File},
+				Children: []*uast.Node{{
+					InternalType: "ListOfDecl",
+					Properties:   map[string]string{"InternalName": "Decls", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "BadDecl",
+						Roles:        []uast.Role{uast.Incomplete},
+						Properties:   map[string]string{"internalRole": "Children"},
+					}},
+				}},
+			},
+		},
+		{
+			name: "imports only",
+			code: "package p\n\nimport \"fmt\"",
+			in: &uast.Node{
+				InternalType: "File",
+				Children: []*uast.Node{{
+					InternalType: "Ident",
+					Properties:   map[string]string{"InternalName": "Name", "Name": "p", "internalRole": "Children"},
+				}, {
+					InternalType: "ListOfDecl",
+					Properties:   map[string]string{"InternalName": "Decls", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "GenDecl",
+						Properties:   map[string]string{"Tok": "import", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "ListOfSpec",
+							Properties:   map[string]string{"InternalName": "Specs", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "ImportSpec",
+								Properties:   map[string]string{"internalRole": "Children"},
+								Children: []*uast.Node{{
+									InternalType: "BasicLit",
+									Properties:   map[string]string{"InternalName": "Path", "Kind": "STRING", "Value": "\"fmt\"", "internalRole": "Children"},
+								}},
+							}},
+						}},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "File",
+				Roles:        []uast.Role{uast.File},
+				Children: []*uast.Node{{
+					InternalType: "Ident",
+					Roles:        []uast.Role{uast.Identifier},
+					Properties:   map[string]string{"InternalName": "Name", "Name": "p", "internalRole": "Children"},
+				}, {
+					InternalType: "ListOfDecl",
+					Properties:   map[string]string{"InternalName": "Decls", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "GenDecl",
+						Properties:   map[string]string{"Tok": "import", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "ListOfSpec",
+							Properties:   map[string]string{"InternalName": "Specs", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "ImportSpec",
+								Properties:   map[string]string{"internalRole": "Children"},
+								Children: []*uast.Node{{
+									InternalType: "BasicLit",
+									Properties:   map[string]string{"InternalName": "Path", "Kind": "STRING", "Value": "\"fmt\"", "internalRole": "Children"},
+								}},
+							}},
+						}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "directives",
+			code: "//go:noinline x",
+			in: &uast.Node{
+				InternalType: "Comment",
+				Properties:   map[string]string{"Text": "//go:noinline x", "internalRole": "Children"},
+				Children: []*uast.Node{{
+					InternalType: "Directive",
+					Properties:   map[string]string{"InternalName": "Directive", "Tool": "go", "Name": "noinline", "Args": "x", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfDirectiveArg",
+						Properties:   map[string]string{"InternalName": "ArgList", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "DirectiveArg",
+							Properties:   map[string]string{"Value": "x", "internalRole": "Children"},
+						}},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "Comment",
+				Roles:        []uast.Role{uast.File},
+				Properties:   map[string]string{"Text": "//go:noinline x", "internalRole": "Children"},
+				Children: []*uast.Node{{
+					InternalType: "Directive",
+					Roles:        []uast.Role{uast.Annotation},
+					Properties:   map[string]string{"InternalName": "Directive", "Tool": "go", "Name": "noinline", "Args": "x", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfDirectiveArg",
+						Properties:   map[string]string{"InternalName": "ArgList", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "DirectiveArg",
+							Roles:        []uast.Role{uast.Argument},
+							Properties:   map[string]string{"Value": "x", "internalRole": "Children"},
+						}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "struct tags",
+			code: "`json:\"a,omitempty\"`",
+			in: &uast.Node{
+				InternalType: "BasicLit",
+				Properties:   map[string]string{"InternalName": "Tag", "Kind": "STRING", "Value": "`json:\"a,omitempty\"`", "internalRole": "Children"},
+				Children: []*uast.Node{{
+					InternalType: "ListOfStructTagKey",
+					Properties:   map[string]string{"InternalName": "StructTag", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "StructTagKey",
+						Properties:   map[string]string{"Name": "a", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "StructTagText",
+							Properties:   map[string]string{"InternalName": "Key", "Value": "json", "internalRole": "Children"},
+						}, {
+							InternalType: "StructTagText",
+							Properties:   map[string]string{"InternalName": "Value", "Value": "a,omitempty", "internalRole": "Children"},
+						}, {
+							InternalType: "ListOfStructTagOption",
+							Properties:   map[string]string{"InternalName": "Options", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "StructTagOption",
+								Properties:   map[string]string{"Value": "omitempty", "internalRole": "Children"},
+							}},
+						}},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "BasicLit",
+				Roles:        []uast.Role{uast.File},
+				Properties:   map[string]string{"InternalName": "Tag", "Kind": "STRING", "Value": "`json:\"a,omitempty\"`", "internalRole": "Children"},
+				Children: []*uast.Node{{
+					InternalType: "ListOfStructTagKey",
+					Properties:   map[string]string{"InternalName": "StructTag", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "StructTagKey",
+						Roles:        []uast.Role{uast.Entry},
+						Properties:   map[string]string{"Name": "a", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "StructTagText",
+							Roles:        []uast.Role{uast.Key},
+							Properties:   map[string]string{"InternalName": "Key", "Value": "json", "internalRole": "Children"},
+						}, {
+							InternalType: "StructTagText",
+							Roles:        []uast.Role{uast.Value},
+							Properties:   map[string]string{"InternalName": "Value", "Value": "a,omitempty", "internalRole": "Children"},
+						}, {
+							InternalType: "ListOfStructTagOption",
+							Properties:   map[string]string{"InternalName": "Options", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "StructTagOption",
+								Roles:        []uast.Role{uast.Value},
+								Properties:   map[string]string{"Value": "omitempty", "internalRole": "Children"},
+							}},
+						}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "cgo",
+			code: "// #cgo LDFLAGS: -lpng\nimport \"C\"",
+			in: &uast.Node{
+				InternalType: "ImportSpec",
+				Children: []*uast.Node{{
+					InternalType: "CgoPreamble",
+					Properties:   map[string]string{"InternalName": "Preamble", "Text": " #cgo LDFLAGS: -lpng\n", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfCgoDirective",
+						Properties:   map[string]string{"InternalName": "Directives", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "CgoDirective",
+							Properties:   map[string]string{"Verb": "LDFLAGS", "Args": "-lpng", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "ListOfCgoArg",
+								Properties:   map[string]string{"InternalName": "ArgList", "internalRole": "Children"},
+								Children: []*uast.Node{{
+									InternalType: "CgoArg",
+									Properties:   map[string]string{"Value": "-lpng", "internalRole": "Children"},
+								}},
+							}},
+						}},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "ImportSpec",
+				Roles:        []uast.Role{uast.File},
+				Children: []*uast.Node{{
+					InternalType: "CgoPreamble",
+					Roles:        []uast.Role{uast.Comment},
+					Properties:   map[string]string{"InternalName": "Preamble", "Text": " #cgo LDFLAGS: -lpng\n", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfCgoDirective",
+						Properties:   map[string]string{"InternalName": "Directives", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "CgoDirective",
+							Roles:        []uast.Role{uast.Annotation},
+							Properties:   map[string]string{"Verb": "LDFLAGS", "Args": "-lpng", "internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "ListOfCgoArg",
+								Properties:   map[string]string{"InternalName": "ArgList", "internalRole": "Children"},
+								Children: []*uast.Node{{
+									InternalType: "CgoArg",
+									Roles:        []uast.Role{uast.Argument},
+									Properties:   map[string]string{"Value": "-lpng", "internalRole": "Children"},
+								}},
+							}},
+						}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "go.mod",
+			code: "module m\n\nreplace a => ./a // local\n",
+			in: &uast.Node{
+				InternalType: "ModFile",
+				Children: []*uast.Node{{
+					InternalType: "Module",
+					Properties:   map[string]string{"internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "Path",
+						Properties:   map[string]string{"InternalName": "Path", "Value": "m", "internalRole": "Children"},
+					}},
+				}, {
+					InternalType: "Replace",
+					Properties:   map[string]string{"internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "Path",
+						Properties:   map[string]string{"InternalName": "Old", "Value": "a", "internalRole": "Children"},
+					}, {
+						InternalType: "Path",
+						Properties:   map[string]string{"InternalName": "New", "Value": "./a", "internalRole": "Children"},
+					}},
+				}, {
+					InternalType: "ListOfComment",
+					Properties:   map[string]string{"InternalName": "Comments", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "Comment",
+						Properties:   map[string]string{"Text": "// local", "internalRole": "Children"},
+					}},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "ModFile",
+				Roles:        []uast.Role{uast.File},
+				Children: []*uast.Node{{
+					InternalType: "Module",
+					Roles:        []uast.Role{uast.Module, uast.Declaration},
+					Properties:   map[string]string{"internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "Path",
+						Roles:        []uast.Role{uast.Module, uast.Pathname, uast.Name},
+						Properties:   map[string]string{"InternalName": "Path", "Value": "m", "internalRole": "Children"},
+					}},
+				}, {
+					InternalType: "Replace",
+					Roles:        []uast.Role{uast.Import, uast.Alias},
+					Properties:   map[string]string{"internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "Path",
+						Roles:        []uast.Role{uast.Import, uast.Pathname},
+						Properties:   map[string]string{"InternalName": "Old", "Value": "a", "internalRole": "Children"},
+					}, {
+						InternalType: "Path",
+						Roles:        []uast.Role{uast.Import, uast.Pathname, uast.Alias},
+						Properties:   map[string]string{"InternalName": "New", "Value": "./a", "internalRole": "Children"},
+					}},
+				}, {
+					InternalType: "ListOfComment",
+					Properties:   map[string]string{"InternalName": "Comments", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "Comment",
+						Roles:        []uast.Role{uast.Comment},
+						Properties:   map[string]string{"Text": "// local", "internalRole": "Children"},
+					}},
+				}},
+			},
+		},
+		{
+			name: "generics",
+			code: "",
+			in: &uast.Node{
+				InternalType: "TypeSpec",
+				Children: []*uast.Node{{
+					InternalType: "FieldList",
+					Properties:   map[string]string{"InternalName": "TypeParams", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfField",
+						Properties:   map[string]string{"InternalName": "List", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "Field",
+							Properties:   map[string]string{"internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "UnaryExpr",
+								Properties:   map[string]string{"InternalName": "Type", "Op": "~", "internalRole": "Children"},
+								Children: []*uast.Node{{
+									InternalType: "Ident",
+									Properties:   map[string]string{"InternalName": "X", "Name": "int", "internalRole": "Children"},
+								}},
+							}},
+						}},
+					}},
+				}, {
+					InternalType: "IndexListExpr",
+					Properties:   map[string]string{"InternalName": "Type", "internalRole": "Children"},
+				}},
+			},
+			out: &uast.Node{
+				InternalType: "TypeSpec",
+				Roles:        []uast.Role{uast.File},
+				Children: []*uast.Node{{
+					InternalType: "FieldList",
+					Roles:        []uast.Role{uast.Type, uast.Declaration, uast.List},
+					Properties:   map[string]string{"InternalName": "TypeParams", "internalRole": "Children"},
+					Children: []*uast.Node{{
+						InternalType: "ListOfField",
+						Properties:   map[string]string{"InternalName": "List", "internalRole": "Children"},
+						Children: []*uast.Node{{
+							InternalType: "Field",
+							Roles:        []uast.Role{uast.Type, uast.Declaration, uast.Argument},
+							Properties:   map[string]string{"internalRole": "Children"},
+							Children: []*uast.Node{{
+								InternalType: "UnaryExpr",
+								Roles:        []uast.Role{uast.Type, uast.Implements, uast.Base},
+								Properties:   map[string]string{"InternalName": "Type", "Op": "~", "internalRole": "Children"},
+								Children: []*uast.Node{{
+									InternalType: "Ident",
+									Roles:        []uast.Role{uast.Identifier},
+									Properties:   map[string]string{"InternalName": "X", "Name": "int", "internalRole": "Children"},
+								}},
+							}},
+						}},
+					}},
+				}, {
+					InternalType: "IndexListExpr",
+					Roles:        []uast.Role{uast.Type, uast.Instance},
+					Properties:   map[string]string{"InternalName": "Type", "internalRole": "Children"},
+				}},
+			},
+		},
 	}
 
 	opts := []cmp.Option{}
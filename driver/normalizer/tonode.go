@@ -2,6 +2,8 @@ package normalizer
 
 import (
 	"log"
+	"math"
+	"strconv"
 
 	"gopkg.in/bblfsh/sdk.v1/uast"
 )
@@ -14,6 +16,10 @@ var ToNode = &uast.ObjectToNode{
 	InternalTypeKey:    "InternalType",
 	OffsetKey:          "StartOffset",
 	EndOffsetKey:       "EndOffset",
+	LineKey:            "StartLine",
+	ColumnKey:          "StartColumn",
+	EndLineKey:         "EndLine",
+	EndColumnKey:       "EndColumn",
 	TopLevelIsRootNode: true,
 
 	Modifier: func(m map[string]interface{}) error {
@@ -26,7 +32,7 @@ var ToNode = &uast.ObjectToNode{
 			if _, ok := m[k]; ok {
 				log.Printf("ignoring already defined property %s", k)
 			} else {
-				m[k] = v
+				m[k] = propertyValue(v)
 				delete(props, k)
 			}
 		}
@@ -34,3 +40,20 @@ var ToNode = &uast.ObjectToNode{
 		return nil
 	},
 }
+
+// propertyValue returns the UAST property value of v, a string, a boolean or
+// a number of the native tree. The properties of the UAST are strings, so
+// numbers, which are all integers, are written in decimal instead of the
+// exponent form fmt.Sprint would use for large float64 values.
+func propertyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return v
+}
@@ -41,6 +41,21 @@ func TestToNode(t *testing.T) {
 				EndPosition:   &uast.Position{Offset: 10},
 			},
 		},
+		{
+			name: "lines and columns",
+			in: m{
+				"StartOffset": float64(12),
+				"EndOffset":   float64(30),
+				"StartLine":   float64(2),
+				"StartColumn": float64(3),
+				"EndLine":     float64(4),
+				"EndColumn":   float64(1),
+			},
+			out: &uast.Node{
+				StartPosition: &uast.Position{Offset: 12, Line: 2, Col: 3},
+				EndPosition:   &uast.Position{Offset: 30, Line: 4, Col: 1},
+			},
+		},
 		{
 			name: "other properties",
 			in: m{
@@ -58,6 +73,25 @@ func TestToNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "typed properties",
+			in: m{
+				"InternalType": "ChanType",
+				"Properties": m{
+					"Dir":        float64(2),
+					"NodeID":     float64(1234567),
+					"Incomplete": false,
+				},
+			},
+			out: &uast.Node{
+				InternalType: "ChanType",
+				Properties: map[string]string{
+					"Dir":        "2",
+					"NodeID":     "1234567",
+					"Incomplete": "false",
+				},
+			},
+		},
 		{
 			name: "repeated properties",
 			in: m{
@@ -296,6 +330,40 @@ func TestToNode(t *testing.T) {
 				EndPosition:   &uast.Position{Offset: 20},
 			},
 		},
+		{
+			name: "parse errors",
+			in: m{
+				"Root": m{
+					"InternalType": "File",
+					"EndOffset":    float64(28),
+				},
+				"Errors": []interface{}{
+					m{
+						"InternalType": "ParseError",
+						"Message":      "expected operand, found ')'",
+						"StartOffset":  float64(19),
+						"StartLine":    float64(3),
+						"StartColumn":  float64(9),
+					},
+				},
+			},
+			out: &uast.Node{
+				Children: []*uast.Node{{
+					InternalType: "ParseError",
+					Properties: map[string]string{
+						"Message":      "expected operand, found ')'",
+						"internalRole": "Errors",
+					},
+					StartPosition: &uast.Position{Offset: 19, Line: 3, Col: 9},
+				}, {
+					InternalType: "File",
+					Properties: map[string]string{
+						"internalRole": "Root",
+					},
+					EndPosition: &uast.Position{Offset: 28},
+				}},
+			},
+		},
 	}
 
 	ignoreRoles := cmp.Comparer(func(a, b []uast.Role) bool { return true })
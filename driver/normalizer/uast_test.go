@@ -0,0 +1,58 @@
+package normalizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/bblfsh/sdk.v1/protocol"
+)
+
+var update = flag.Bool("update", false, "update the UAST in the testdata of the native driver")
+
+// testdata holds the tree of print.go written by the native driver, whose
+// tests print the UAST made of it back into print.go.
+var testdata = filepath.Join("..", "..", "native", "testdata")
+
+func TestNativeUAST(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join(testdata, "print.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(testdata, "print.native.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := ToNode.ToNode(tree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tr := range Transformers {
+		if err := tr.Do(string(src), protocol.UTF8, n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	got, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join(testdata, "print.uast.json")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, err := os.ReadFile(path); err != nil || !bytes.Equal(want, got) {
+		t.Fatalf("%s is out of date, run the tests with -update: %v", path, err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"path"
+	"strings"
+)
+
+// addFileInfo sets the properties of a File node that depend on its name and
+// build constraints: Filename, IsTest, GOOS and GOARCH for the filename
+// suffixes and BuildConstraint for the //go:build or // +build lines.
+func addFileInfo(f *ast.File, filename string, s sink) {
+	if expr := buildConstraint(f); expr != nil {
+		s.property("BuildConstraint", expr.String())
+	}
+	if filename == "" {
+		return
+	}
+
+	s.property("Filename", filename)
+	s.property("IsTest", strings.HasSuffix(filename, "_test.go"))
+	goos, goarch := osArchSuffix(filename)
+	if goos != "" {
+		s.property("GOOS", goos)
+	}
+	if goarch != "" {
+		s.property("GOARCH", goarch)
+	}
+}
+
+// buildConstraint returns the build constraint of f, if any. A //go:build
+// line takes precedence over // +build lines, which are combined otherwise.
+func buildConstraint(f *ast.File) constraint.Expr {
+	var plus constraint.Expr
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if constraint.IsGoBuild(c.Text) {
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					return expr
+				}
+			} else if constraint.IsPlusBuild(c.Text) {
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					continue
+				}
+				if plus == nil {
+					plus = expr
+				} else {
+					plus = &constraint.AndExpr{X: plus, Y: expr}
+				}
+			}
+		}
+	}
+	return plus
+}
+
+// osArchSuffix returns the GOOS and GOARCH implied by the name of a file,
+// following the same rules as go/build: name_$(GOOS).go, name_$(GOARCH).go
+// and name_$(GOOS)_$(GOARCH).go, all of them optionally followed by _test.
+func osArchSuffix(filename string) (goos, goarch string) {
+	name := path.Base(filename)
+	name, _, _ = strings.Cut(name, ".")
+
+	// Everything before the first _ is ignored, so that a file named after
+	// an OS or architecture is not restricted to it.
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return "", ""
+	}
+	l := strings.Split(name[i:], "_")
+	if n := len(l); n > 0 && l[n-1] == "test" {
+		l = l[:n-1]
+	}
+
+	n := len(l)
+	if n >= 2 && knownOS[l[n-2]] && knownArch[l[n-1]] {
+		return l[n-2], l[n-1]
+	}
+	if n >= 1 && knownOS[l[n-1]] {
+		return l[n-1], ""
+	}
+	if n >= 1 && knownArch[l[n-1]] {
+		return "", l[n-1]
+	}
+	return "", ""
+}
+
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true, "js": true,
+	"linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "wasip1": true, "windows": true,
+	"zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true,
+	"arm64": true, "arm64be": true, "loong64": true, "mips": true,
+	"mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true,
+	"riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFileInfo(t *testing.T) {
+	tt := []struct {
+		name     string
+		filename string
+		content  string
+		props    map[string]interface{}
+	}{
+		{
+			name:    "no filename",
+			content: "package p",
+		},
+		{
+			name:     "plain file",
+			filename: "p.go",
+			content:  "package p",
+			props:    props("Filename", "p.go", "IsTest", false),
+		},
+		{
+			name:     "test file",
+			filename: "dir/p_test.go",
+			content:  "package p",
+			props:    props("Filename", "dir/p_test.go", "IsTest", true),
+		},
+		{
+			name:     "os and arch suffixes",
+			filename: "p_linux_amd64_test.go",
+			content:  "package p",
+			props:    props("Filename", "p_linux_amd64_test.go", "IsTest", true, "GOOS", "linux", "GOARCH", "amd64"),
+		},
+		{
+			name:     "os suffix",
+			filename: "p_windows.go",
+			content:  "package p",
+			props:    props("Filename", "p_windows.go", "IsTest", false, "GOOS", "windows"),
+		},
+		{
+			name:     "arch suffix",
+			filename: "p_arm64.go",
+			content:  "package p",
+			props:    props("Filename", "p_arm64.go", "IsTest", false, "GOARCH", "arm64"),
+		},
+		{
+			name:     "named after an os",
+			filename: "linux.go",
+			content:  "package p",
+			props:    props("Filename", "linux.go", "IsTest", false),
+		},
+		{
+			name:    "go:build line",
+			content: "//go:build linux && !cgo\n// +build linux,!cgo\n\npackage p",
+			props:   props("BuildConstraint", "linux && !cgo"),
+		},
+		{
+			name:    "+build lines",
+			content: "// +build linux darwin\n// +build amd64\n\n// Package p.\npackage p",
+			props:   props("BuildConstraint", "(linux || darwin) && amd64"),
+		},
+		{
+			name:    "go version",
+			content: "//go:build go1.21\n\npackage p",
+			props:   props("BuildConstraint", "go1.21", "GoVersion", "go1.21"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parse(&request{Filename: tc.filename, Content: tc.content})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !cmp.Equal(tc.props, res.Properties, cmpopts.EquateEmpty()) {
+				t.Fatalf("different properties: %s", cmp.Diff(tc.props, res.Properties, cmpopts.EquateEmpty()))
+			}
+		})
+	}
+}
+
+func TestFilenameInErrors(t *testing.T) {
+	res := handle(&request{Filename: "p.go", Content: "package p\n)"})
+	if want := []string{"p.go:2:1: expected declaration, found ')'"}; !cmp.Equal(want, res.Errors) {
+		t.Fatalf("different errors: %s", cmp.Diff(want, res.Errors))
+	}
+	if got := res.AST.Errors[0].Filename; got != "p.go" {
+		t.Fatalf("expected error in p.go; got %q", got)
+	}
+}
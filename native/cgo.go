@@ -0,0 +1,214 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cgoImports returns the import "C" specs of f, along with their preamble:
+// the comment right before the spec, or before the declaration if it's the
+// only spec in it, as cgo finds it. Specs without a preamble hold nil.
+func cgoImports(f *ast.File) map[*ast.ImportSpec]*ast.CommentGroup {
+	imports := make(map[*ast.ImportSpec]*ast.CommentGroup)
+	for _, d := range f.Decls {
+		d, ok := d.(*ast.GenDecl)
+		if !ok || d.Tok != token.IMPORT {
+			continue
+		}
+		for _, s := range d.Specs {
+			s := s.(*ast.ImportSpec)
+			if path, err := strconv.Unquote(s.Path.Value); err != nil || path != "C" {
+				continue
+			}
+			cg := s.Doc
+			if cg == nil && len(d.Specs) == 1 {
+				cg = d.Doc
+			}
+			imports[s] = cg
+		}
+	}
+	return imports
+}
+
+// addCgoRef sets the CgoRef property of the C.xxx references of files
+// importing "C" to the name of the C object they refer to.
+func (c *converter) addCgoRef(n *ast.SelectorExpr) {
+	if len(c.cgo) == 0 {
+		return
+	}
+	if x, ok := n.X.(*ast.Ident); ok && x.Name == "C" && x.Obj == nil {
+		c.out.property("CgoRef", n.Sel.Name)
+	}
+}
+
+// cgoPreamble writes the CgoPreamble node of an import "C" spec, named
+// Preamble, if it has one. Its Text is the C code of the comments, without
+// their markers, and its Directives the #cgo lines found in it.
+func (c *converter) cgoPreamble(spec *ast.ImportSpec) {
+	cg := c.cgo[spec]
+	if cg == nil {
+		return
+	}
+
+	c.out.openNode("CgoPreamble", "Preamble", c.position(cg.Pos()), c.position(cg.End()))
+	var text strings.Builder
+	for _, com := range cg.List {
+		if com.Text[1] == '/' {
+			text.WriteString(com.Text[2:] + "\n")
+		} else {
+			text.WriteString(com.Text[2 : len(com.Text)-2])
+		}
+	}
+	c.out.property("Text", text.String())
+
+	var directives []cgoDirective
+	for _, com := range cg.List {
+		directives = append(directives, cgoDirectives(com)...)
+	}
+	if len(directives) > 0 {
+		c.out.openNode("ListOfCgoDirective", "Directives", position{}, position{})
+		for _, d := range directives {
+			c.writeCgoDirective(d)
+		}
+		c.out.closeNode()
+	}
+	c.out.closeNode()
+}
+
+// cgoDirective is a #cgo line of a preamble, such as
+//
+//	#cgo linux,amd64 LDFLAGS: -lpng
+//
+// whose Verb is LDFLAGS, Constraint linux,amd64 and Args -lpng. The
+// nocallback and noescape directives have no colon.
+type cgoDirective struct {
+	verb, constraint, args string
+	start, end, argsStart  token.Pos
+}
+
+// cgoDirectives returns the #cgo lines of the comment com. Lines cgo would
+// reject for lacking a verb are left out.
+func cgoDirectives(com *ast.Comment) []cgoDirective {
+	text := com.Text[2:]
+	if com.Text[1] == '*' {
+		text = text[:len(text)-2]
+	}
+	start := com.Slash + 2
+
+	var directives []cgoDirective
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lineStart := start
+		start += token.Pos(len(line))
+
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		offset := lineStart + token.Pos(len(line)-len(trimmed))
+		trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+		if len(trimmed) < 5 || trimmed[:4] != "#cgo" || trimmed[4] != ' ' && trimmed[4] != '\t' {
+			continue
+		}
+		d := cgoDirective{start: offset, end: offset + token.Pos(len(trimmed))}
+
+		if fields := strings.Fields(trimmed); len(fields) == 3 && (fields[1] == "nocallback" || fields[1] == "noescape") {
+			d.verb, d.args = fields[1], fields[2]
+			d.argsStart = d.end - token.Pos(len(d.args))
+			directives = append(directives, d)
+			continue
+		}
+
+		head, args, ok := strings.Cut(trimmed[4:], ":")
+		f := strings.Fields(head)
+		if !ok || len(f) == 0 {
+			continue
+		}
+		d.verb = f[len(f)-1]
+		d.constraint = strings.Join(f[:len(f)-1], " ")
+		d.args = strings.TrimSpace(args)
+		d.argsStart = d.end - token.Pos(len(d.args))
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+// writeCgoDirective writes the CgoDirective node of d. Its arguments are
+// split as the go command does, into the CgoArg nodes of its ArgList with
+// their unquoted text as Value, unless their quotes aren't closed.
+func (c *converter) writeCgoDirective(d cgoDirective) {
+	c.out.openNode("CgoDirective", "", c.position(d.start), c.position(d.end))
+	c.out.property("Verb", d.verb)
+	if d.constraint != "" {
+		c.out.property("Constraint", d.constraint)
+	}
+	if d.args != "" {
+		c.out.property("Args", d.args)
+	}
+
+	if args, ok := splitCgoArgs(d.args); ok && len(args) > 0 {
+		c.out.openNode("ListOfCgoArg", "ArgList", position{}, position{})
+		for _, a := range args {
+			c.out.openNode("CgoArg", "", c.position(d.argsStart+token.Pos(a.start)), c.position(d.argsStart+token.Pos(a.end)))
+			c.out.property("Value", a.value)
+			c.out.closeNode()
+		}
+		c.out.closeNode()
+	}
+	c.out.closeNode()
+}
+
+// cgoArg is an argument of a #cgo directive, at offsets start to end of its
+// arguments.
+type cgoArg struct {
+	value      string
+	start, end int
+}
+
+// splitCgoArgs splits the arguments of a #cgo directive at spaces, which
+// can be escaped or quoted with single or double quotes, as the go command
+// does. It returns false if a quote or escape is left unfinished.
+func splitCgoArgs(s string) ([]cgoArg, bool) {
+	var (
+		args    []cgoArg
+		arg     []byte
+		escaped bool
+		quoted  bool
+		quote   rune
+		start   = -1
+	)
+	add := func(end int) {
+		args = append(args, cgoArg{value: string(arg), start: start, end: end})
+		arg, quoted, start = arg[:0], false, -1
+	}
+	for i, r := range s {
+		if start < 0 && !unicode.IsSpace(r) {
+			start = i
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+			continue
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+		case r == '"' || r == '\'':
+			quoted, quote = true, r
+			continue
+		case unicode.IsSpace(r):
+			if quoted || len(arg) > 0 {
+				add(i)
+			}
+			continue
+		}
+		arg = utf8.AppendRune(arg, r)
+	}
+	if quoted || len(arg) > 0 {
+		add(len(s))
+	}
+	return args, quote == 0 && !escaped
+}
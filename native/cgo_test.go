@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCgo = `package p
+
+/*
+#cgo CFLAGS: -DPNG_DEBUG=1 "-I/opt/my dir"
+#cgo linux,amd64 LDFLAGS: -lpng
+#include <png.h>
+*/
+// #cgo pkg-config: png cairo
+// #cgo noescape png_free
+import "C"
+
+import "unsafe"
+
+func f() {
+	p := C.malloc(C.size_t(8))
+	C.free(unsafe.Pointer(p))
+}
+`
+
+func TestCgoPreamble(t *testing.T) {
+	res, err := parse(&request{Content: testCgo})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := find(res, "CgoPreamble", "", nil)
+	if p == nil || p.InternalName != "Preamble" {
+		t.Fatalf("expected a preamble; got %+v", p)
+	}
+	if want := strings.Index(testCgo, "/*"); int(p.StartOffset) != want || int(p.EndOffset) != strings.Index(testCgo, "\nimport \"C\"") {
+		t.Errorf("expected the preamble to span the comments; got %d-%d", p.StartOffset, p.EndOffset)
+	}
+	want := "\n#cgo CFLAGS: -DPNG_DEBUG=1 \"-I/opt/my dir\"\n#cgo linux,amd64 LDFLAGS: -lpng\n#include <png.h>\n" +
+		" #cgo pkg-config: png cairo\n #cgo noescape png_free\n"
+	if p.Properties["Text"] != want {
+		t.Errorf("expected the text %q; got %q", want, p.Properties["Text"])
+	}
+
+	tt := []struct {
+		verb, constraint string
+		values           []string
+	}{
+		{"CFLAGS", "", []string{"-DPNG_DEBUG=1", "-I/opt/my dir"}},
+		{"LDFLAGS", "linux,amd64", []string{"-lpng"}},
+		{"pkg-config", "", []string{"png", "cairo"}},
+		{"noescape", "", []string{"png_free"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.verb, func(t *testing.T) {
+			d := find(p, "CgoDirective", "Verb", tc.verb)
+			if d == nil {
+				t.Fatalf("directive not found")
+			}
+			constraint, _ := d.Properties["Constraint"].(string)
+			if constraint != tc.constraint {
+				t.Errorf("expected the constraint %q; got %q", tc.constraint, constraint)
+			}
+			line := "#cgo " + tc.verb
+			if tc.constraint != "" {
+				line = "#cgo " + tc.constraint + " " + tc.verb
+			}
+			if want := strings.Index(testCgo, line); int(d.StartOffset) != want {
+				t.Errorf("expected the directive at offset %d; got %d", want, d.StartOffset)
+			}
+
+			var values []string
+			for _, a := range d.Children[0].Children {
+				values = append(values, a.Properties["Value"].(string))
+				if raw := testCgo[a.StartOffset:a.EndOffset]; strings.Trim(raw, `"`) != a.Properties["Value"] {
+					t.Errorf("argument %q found at %q", a.Properties["Value"], raw)
+				}
+			}
+			if strings.Join(values, "|") != strings.Join(tc.values, "|") {
+				t.Errorf("expected the arguments %q; got %q", tc.values, values)
+			}
+		})
+	}
+}
+
+func TestCgoRefs(t *testing.T) {
+	res, err := parse(&request{Content: testCgo})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"malloc", "size_t", "free"} {
+		if find(res, "SelectorExpr", "CgoRef", name) == nil {
+			t.Errorf("C.%s not marked", name)
+		}
+	}
+	if s := find(res, "SelectorExpr", "CgoRef", "Pointer"); s != nil {
+		t.Errorf("unsafe.Pointer marked: %+v", s)
+	}
+
+	// C is just a package name without import "C".
+	res, err = parse(&request{Content: "package p\n\nimport C \"fmt\"\n\nvar _ = C.Sprint"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := find(res, "SelectorExpr", "", nil); s.Properties["CgoRef"] != nil {
+		t.Errorf("unexpected CgoRef: %+v", s)
+	}
+}
+
+func TestSplitCgoArgs(t *testing.T) {
+	tt := []struct {
+		in   string
+		want []string
+		ok   bool
+	}{
+		{"", nil, true},
+		{"-a  -b", []string{"-a", "-b"}, true},
+		{`"a b" 'c d' e\ f ""`, []string{"a b", "c d", "e f", ""}, true},
+		{`-D"x y"z`, []string{"-Dx yz"}, true},
+		{`"open`, []string{"open"}, false},
+		{`end\`, []string{"end"}, false},
+	}
+	for _, tc := range tt {
+		args, ok := splitCgoArgs(tc.in)
+		var got []string
+		for _, a := range args {
+			got = append(got, a.value)
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) || ok != tc.ok {
+			t.Errorf("splitCgoArgs(%q) = %q, %v; want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestPrintCgo(t *testing.T) {
+	if got := printTree(t, testCgo); got != testCgo {
+		t.Errorf("different code:\n%s", got)
+	}
+}
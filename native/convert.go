@@ -0,0 +1,149 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+//go:generate go run gen_convert.go
+
+// converter writes the go/ast nodes of a file into a sink. The conversion of
+// each node type is generated in convert_gen.go.
+//
+// Trees are walked with an explicit stack instead of recursion, as generated
+// code can nest expressions hundreds of thousands of levels deep. How deep
+// they may go is capped by the -max-depth flag instead.
+type converter struct {
+	out sink
+	// stack holds what's left to be written of the nodes being converted.
+	stack []task
+	fs    *token.FileSet
+	locator
+	// filename is the name of the file, if known, and src its content.
+	filename string
+	src      string
+	// pkg and info hold the type information of the tree, if it was type
+	// checked.
+	pkg  *types.Package
+	info *types.Info
+	// ids holds the IDs of the declaring nodes.
+	ids map[ast.Node]int
+	// targets holds the nodes the directive comments attach to.
+	targets map[*ast.Comment]ast.Node
+	// cgo holds the import "C" specs and their preambles.
+	cgo map[*ast.ImportSpec]*ast.CommentGroup
+	// starts and ends hold the positions of the nodes that were found while
+	// computing those of their ancestors.
+	starts, ends map[ast.Node]token.Pos
+}
+
+func newConverter(out sink, fs *token.FileSet, f *ast.File, ids map[ast.Node]int) *converter {
+	return &converter{
+		out:     out,
+		fs:      fs,
+		locator: newLocator(fs.File(f.Package)),
+		ids:     ids,
+		targets: directiveTargets(f),
+		cgo:     cgoImports(f),
+		starts:  make(map[ast.Node]token.Pos),
+		ends:    make(map[ast.Node]token.Pos),
+	}
+}
+
+// file converts f into a File node. Only the comments not attached to other
+// nodes are kept in its Comments.
+func (c *converter) file(f *ast.File) {
+	f.Comments = freeComments(f)
+	c.walk(f, "")
+}
+
+// task is a step of the conversion of a tree: converting a node, opening a
+// list of nodes or closing the last node opened.
+type task struct {
+	n    ast.Node
+	name string
+	list string
+}
+
+// walk converts n, named name, and all its descendants.
+func (c *converter) walk(n ast.Node, name string) {
+	base := len(c.stack)
+	c.push(n, name)
+	c.run(base)
+}
+
+// run carries out the tasks of the stack above base, and those they add.
+func (c *converter) run(base int) {
+	for len(c.stack) > base {
+		t := c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+
+		switch {
+		case t.n != nil:
+			c.convert(t.n, t.name)
+		case t.list != "":
+			c.out.openNode(t.list, t.name, position{}, position{})
+		default:
+			c.out.closeNode()
+		}
+	}
+}
+
+// push schedules the conversion of n, named name, if it's not nil.
+func (c *converter) push(n ast.Node, name string) {
+	if n != nil {
+		c.stack = append(c.stack, task{n: n, name: name})
+	}
+}
+
+// pushList schedules the opening of a list of type typ, named name.
+func (c *converter) pushList(typ, name string) {
+	c.stack = append(c.stack, task{name: name, list: typ})
+}
+
+// pushClose schedules the closing of the node opened last.
+func (c *converter) pushClose() {
+	c.stack = append(c.stack, task{})
+}
+
+// open starts the node for n, along with the properties that don't come
+// from its fields.
+func (c *converter) open(n ast.Node, typ, name string) {
+	c.out.openNode(typ, name, c.position(c.pos(n)), c.position(c.end(n)))
+
+	c.addRefs(n)
+	if c.info != nil {
+		c.addTypes(n)
+	}
+	switch n := n.(type) {
+	case *ast.File:
+		addFileInfo(n, c.filename, c.out)
+		addEncodingInfo(c.src, c.out)
+	case *ast.BasicLit:
+		addLiteral(n, c.out)
+	case *ast.SelectorExpr:
+		c.addCgoRef(n)
+	}
+}
+
+// inspect calls f with root and each of its descendants in depth-first
+// order, like ast.Inspect but without recursion. The children of the nodes
+// for which f returns false are skipped.
+func inspect(root ast.Node, f func(ast.Node) bool) {
+	stack := []ast.Node{root}
+	var kids []ast.Node
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !f(n) {
+			continue
+		}
+
+		kids = kids[:0]
+		children(n, func(k ast.Node) { kids = append(kids, k) })
+		for i := len(kids) - 1; i >= 0; i-- {
+			stack = append(stack, kids[i])
+		}
+	}
+}
@@ -0,0 +1,1236 @@
+// Code generated by gen_convert.go from go/ast; DO NOT EDIT.
+
+package main
+
+import "go/ast"
+
+// convert writes n, as the field name of its parent, into c.out.
+func (c *converter) convert(n ast.Node, name string) {
+	switch n := n.(type) {
+	case *ast.ArrayType:
+		c.convertArrayType(n, name)
+	case *ast.AssignStmt:
+		c.convertAssignStmt(n, name)
+	case *ast.BadDecl:
+		c.convertBadDecl(n, name)
+	case *ast.BadExpr:
+		c.convertBadExpr(n, name)
+	case *ast.BadStmt:
+		c.convertBadStmt(n, name)
+	case *ast.BasicLit:
+		c.convertBasicLit(n, name)
+	case *ast.BinaryExpr:
+		c.convertBinaryExpr(n, name)
+	case *ast.BlockStmt:
+		c.convertBlockStmt(n, name)
+	case *ast.BranchStmt:
+		c.convertBranchStmt(n, name)
+	case *ast.CallExpr:
+		c.convertCallExpr(n, name)
+	case *ast.CaseClause:
+		c.convertCaseClause(n, name)
+	case *ast.ChanType:
+		c.convertChanType(n, name)
+	case *ast.CommClause:
+		c.convertCommClause(n, name)
+	case *ast.Comment:
+		c.convertComment(n, name)
+	case *ast.CommentGroup:
+		c.convertCommentGroup(n, name)
+	case *ast.CompositeLit:
+		c.convertCompositeLit(n, name)
+	case *ast.DeclStmt:
+		c.convertDeclStmt(n, name)
+	case *ast.DeferStmt:
+		c.convertDeferStmt(n, name)
+	case *ast.Ellipsis:
+		c.convertEllipsis(n, name)
+	case *ast.EmptyStmt:
+		c.convertEmptyStmt(n, name)
+	case *ast.ExprStmt:
+		c.convertExprStmt(n, name)
+	case *ast.Field:
+		c.convertField(n, name)
+	case *ast.FieldList:
+		c.convertFieldList(n, name)
+	case *ast.File:
+		c.convertFile(n, name)
+	case *ast.ForStmt:
+		c.convertForStmt(n, name)
+	case *ast.FuncDecl:
+		c.convertFuncDecl(n, name)
+	case *ast.FuncLit:
+		c.convertFuncLit(n, name)
+	case *ast.FuncType:
+		c.convertFuncType(n, name)
+	case *ast.GenDecl:
+		c.convertGenDecl(n, name)
+	case *ast.GoStmt:
+		c.convertGoStmt(n, name)
+	case *ast.Ident:
+		c.convertIdent(n, name)
+	case *ast.IfStmt:
+		c.convertIfStmt(n, name)
+	case *ast.ImportSpec:
+		c.convertImportSpec(n, name)
+	case *ast.IncDecStmt:
+		c.convertIncDecStmt(n, name)
+	case *ast.IndexExpr:
+		c.convertIndexExpr(n, name)
+	case *ast.IndexListExpr:
+		c.convertIndexListExpr(n, name)
+	case *ast.InterfaceType:
+		c.convertInterfaceType(n, name)
+	case *ast.KeyValueExpr:
+		c.convertKeyValueExpr(n, name)
+	case *ast.LabeledStmt:
+		c.convertLabeledStmt(n, name)
+	case *ast.MapType:
+		c.convertMapType(n, name)
+	case *ast.ParenExpr:
+		c.convertParenExpr(n, name)
+	case *ast.RangeStmt:
+		c.convertRangeStmt(n, name)
+	case *ast.ReturnStmt:
+		c.convertReturnStmt(n, name)
+	case *ast.SelectStmt:
+		c.convertSelectStmt(n, name)
+	case *ast.SelectorExpr:
+		c.convertSelectorExpr(n, name)
+	case *ast.SendStmt:
+		c.convertSendStmt(n, name)
+	case *ast.SliceExpr:
+		c.convertSliceExpr(n, name)
+	case *ast.StarExpr:
+		c.convertStarExpr(n, name)
+	case *ast.StructType:
+		c.convertStructType(n, name)
+	case *ast.SwitchStmt:
+		c.convertSwitchStmt(n, name)
+	case *ast.TypeAssertExpr:
+		c.convertTypeAssertExpr(n, name)
+	case *ast.TypeSpec:
+		c.convertTypeSpec(n, name)
+	case *ast.TypeSwitchStmt:
+		c.convertTypeSwitchStmt(n, name)
+	case *ast.UnaryExpr:
+		c.convertUnaryExpr(n, name)
+	case *ast.ValueSpec:
+		c.convertValueSpec(n, name)
+	}
+}
+
+func (c *converter) convertArrayType(n *ast.ArrayType, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ArrayType", name)
+	c.pushClose()
+	c.push(n.Elt, "Elt")
+	c.push(n.Len, "Len")
+}
+
+func (c *converter) convertAssignStmt(n *ast.AssignStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "AssignStmt", name)
+	c.out.property("Tok", n.Tok.String())
+	c.pushClose()
+	if len(n.Rhs) > 0 {
+		c.pushClose()
+		for i := len(n.Rhs) - 1; i >= 0; i-- {
+			c.push(n.Rhs[i], "")
+		}
+		c.pushList("ListOfExpr", "Rhs")
+	}
+	if len(n.Lhs) > 0 {
+		c.pushClose()
+		for i := len(n.Lhs) - 1; i >= 0; i-- {
+			c.push(n.Lhs[i], "")
+		}
+		c.pushList("ListOfExpr", "Lhs")
+	}
+}
+
+func (c *converter) convertBadDecl(n *ast.BadDecl, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BadDecl", name)
+	c.pushClose()
+}
+
+func (c *converter) convertBadExpr(n *ast.BadExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BadExpr", name)
+	c.pushClose()
+}
+
+func (c *converter) convertBadStmt(n *ast.BadStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BadStmt", name)
+	c.pushClose()
+}
+
+func (c *converter) convertBasicLit(n *ast.BasicLit, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BasicLit", name)
+	c.out.property("Kind", n.Kind.String())
+	if n.Value != "" {
+		c.out.property("Value", n.Value)
+	}
+	c.structTag(n, name)
+	c.pushClose()
+}
+
+func (c *converter) convertBinaryExpr(n *ast.BinaryExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BinaryExpr", name)
+	c.out.property("Op", n.Op.String())
+	c.pushClose()
+	c.push(n.Y, "Y")
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertBlockStmt(n *ast.BlockStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BlockStmt", name)
+	c.pushClose()
+	if len(n.List) > 0 {
+		c.pushClose()
+		for i := len(n.List) - 1; i >= 0; i-- {
+			c.push(n.List[i], "")
+		}
+		c.pushList("ListOfStmt", "List")
+	}
+}
+
+func (c *converter) convertBranchStmt(n *ast.BranchStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "BranchStmt", name)
+	c.out.property("Tok", n.Tok.String())
+	c.pushClose()
+	c.push(n.Label, "Label")
+}
+
+func (c *converter) convertCallExpr(n *ast.CallExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "CallExpr", name)
+	if n.Ellipsis.IsValid() {
+		c.out.property("Ellipsis", int(c.position(n.Ellipsis).offset))
+	}
+	c.pushClose()
+	if len(n.Args) > 0 {
+		c.pushClose()
+		for i := len(n.Args) - 1; i >= 0; i-- {
+			c.push(n.Args[i], "")
+		}
+		c.pushList("ListOfExpr", "Args")
+	}
+	c.push(n.Fun, "Fun")
+}
+
+func (c *converter) convertCaseClause(n *ast.CaseClause, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "CaseClause", name)
+	c.pushClose()
+	if len(n.Body) > 0 {
+		c.pushClose()
+		for i := len(n.Body) - 1; i >= 0; i-- {
+			c.push(n.Body[i], "")
+		}
+		c.pushList("ListOfStmt", "Body")
+	}
+	if len(n.List) > 0 {
+		c.pushClose()
+		for i := len(n.List) - 1; i >= 0; i-- {
+			c.push(n.List[i], "")
+		}
+		c.pushList("ListOfExpr", "List")
+	}
+}
+
+func (c *converter) convertChanType(n *ast.ChanType, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ChanType", name)
+	c.out.property("Dir", int(n.Dir))
+	c.pushClose()
+	c.push(n.Value, "Value")
+}
+
+func (c *converter) convertCommClause(n *ast.CommClause, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "CommClause", name)
+	c.pushClose()
+	if len(n.Body) > 0 {
+		c.pushClose()
+		for i := len(n.Body) - 1; i >= 0; i-- {
+			c.push(n.Body[i], "")
+		}
+		c.pushList("ListOfStmt", "Body")
+	}
+	c.push(n.Comm, "Comm")
+}
+
+func (c *converter) convertComment(n *ast.Comment, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "Comment", name)
+	if n.Text != "" {
+		c.out.property("Text", n.Text)
+	}
+	c.directive(n)
+	c.pushClose()
+}
+
+func (c *converter) convertCommentGroup(n *ast.CommentGroup, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "CommentGroup", name)
+	c.pushClose()
+	if len(n.List) > 0 {
+		c.pushClose()
+		for i := len(n.List) - 1; i >= 0; i-- {
+			c.push(n.List[i], "")
+		}
+		c.pushList("ListOfComment", "List")
+	}
+}
+
+func (c *converter) convertCompositeLit(n *ast.CompositeLit, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "CompositeLit", name)
+	c.out.property("Incomplete", n.Incomplete)
+	c.pushClose()
+	if len(n.Elts) > 0 {
+		c.pushClose()
+		for i := len(n.Elts) - 1; i >= 0; i-- {
+			c.push(n.Elts[i], "")
+		}
+		c.pushList("ListOfExpr", "Elts")
+	}
+	c.push(n.Type, "Type")
+}
+
+func (c *converter) convertDeclStmt(n *ast.DeclStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "DeclStmt", name)
+	c.pushClose()
+	c.push(n.Decl, "Decl")
+}
+
+func (c *converter) convertDeferStmt(n *ast.DeferStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "DeferStmt", name)
+	c.pushClose()
+	c.push(n.Call, "Call")
+}
+
+func (c *converter) convertEllipsis(n *ast.Ellipsis, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "Ellipsis", name)
+	c.pushClose()
+	c.push(n.Elt, "Elt")
+}
+
+func (c *converter) convertEmptyStmt(n *ast.EmptyStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "EmptyStmt", name)
+	c.out.property("Implicit", n.Implicit)
+	c.pushClose()
+}
+
+func (c *converter) convertExprStmt(n *ast.ExprStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ExprStmt", name)
+	c.pushClose()
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertField(n *ast.Field, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "Field", name)
+	c.pushClose()
+	c.push(n.Comment, "Comment")
+	c.push(n.Tag, "Tag")
+	c.push(n.Type, "Type")
+	if len(n.Names) > 0 {
+		c.pushClose()
+		for i := len(n.Names) - 1; i >= 0; i-- {
+			c.push(n.Names[i], "")
+		}
+		c.pushList("ListOfIdent", "Names")
+	}
+	c.push(n.Doc, "Doc")
+}
+
+func (c *converter) convertFieldList(n *ast.FieldList, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "FieldList", name)
+	c.pushClose()
+	if len(n.List) > 0 {
+		c.pushClose()
+		for i := len(n.List) - 1; i >= 0; i-- {
+			c.push(n.List[i], "")
+		}
+		c.pushList("ListOfField", "List")
+	}
+}
+
+func (c *converter) convertFile(n *ast.File, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "File", name)
+	if n.GoVersion != "" {
+		c.out.property("GoVersion", n.GoVersion)
+	}
+	c.pushClose()
+	if len(n.Comments) > 0 {
+		c.pushClose()
+		for i := len(n.Comments) - 1; i >= 0; i-- {
+			c.push(n.Comments[i], "")
+		}
+		c.pushList("ListOfCommentGroup", "Comments")
+	}
+	if len(n.Unresolved) > 0 {
+		c.pushClose()
+		for i := len(n.Unresolved) - 1; i >= 0; i-- {
+			c.push(n.Unresolved[i], "")
+		}
+		c.pushList("ListOfIdent", "Unresolved")
+	}
+	if len(n.Decls) > 0 {
+		c.pushClose()
+		for i := len(n.Decls) - 1; i >= 0; i-- {
+			c.push(n.Decls[i], "")
+		}
+		c.pushList("ListOfDecl", "Decls")
+	}
+	c.push(n.Name, "Name")
+	c.push(n.Doc, "Doc")
+}
+
+func (c *converter) convertForStmt(n *ast.ForStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ForStmt", name)
+	c.pushClose()
+	c.push(n.Body, "Body")
+	c.push(n.Post, "Post")
+	c.push(n.Cond, "Cond")
+	c.push(n.Init, "Init")
+}
+
+func (c *converter) convertFuncDecl(n *ast.FuncDecl, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "FuncDecl", name)
+	c.pushClose()
+	c.push(n.Body, "Body")
+	c.push(n.Type, "Type")
+	c.push(n.Name, "Name")
+	c.push(n.Recv, "Recv")
+	c.push(n.Doc, "Doc")
+}
+
+func (c *converter) convertFuncLit(n *ast.FuncLit, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "FuncLit", name)
+	c.pushClose()
+	c.push(n.Body, "Body")
+	c.push(n.Type, "Type")
+}
+
+func (c *converter) convertFuncType(n *ast.FuncType, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "FuncType", name)
+	c.pushClose()
+	c.push(n.Results, "Results")
+	c.push(n.Params, "Params")
+	c.push(n.TypeParams, "TypeParams")
+}
+
+func (c *converter) convertGenDecl(n *ast.GenDecl, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "GenDecl", name)
+	c.out.property("Tok", n.Tok.String())
+	if n.Lparen.IsValid() {
+		c.out.property("Lparen", int(c.position(n.Lparen).offset))
+	}
+	c.pushClose()
+	if len(n.Specs) > 0 {
+		c.pushClose()
+		for i := len(n.Specs) - 1; i >= 0; i-- {
+			c.push(n.Specs[i], "")
+		}
+		c.pushList("ListOfSpec", "Specs")
+	}
+	c.push(n.Doc, "Doc")
+}
+
+func (c *converter) convertGoStmt(n *ast.GoStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "GoStmt", name)
+	c.pushClose()
+	c.push(n.Call, "Call")
+}
+
+func (c *converter) convertIdent(n *ast.Ident, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "Ident", name)
+	if n.Name != "" {
+		c.out.property("Name", n.Name)
+	}
+	c.pushClose()
+}
+
+func (c *converter) convertIfStmt(n *ast.IfStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "IfStmt", name)
+	c.pushClose()
+	c.push(n.Else, "Else")
+	c.push(n.Body, "Body")
+	c.push(n.Cond, "Cond")
+	c.push(n.Init, "Init")
+}
+
+func (c *converter) convertImportSpec(n *ast.ImportSpec, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ImportSpec", name)
+	c.cgoPreamble(n)
+	c.pushClose()
+	c.push(n.Comment, "Comment")
+	c.push(n.Path, "Path")
+	c.push(n.Name, "Name")
+	c.push(n.Doc, "Doc")
+}
+
+func (c *converter) convertIncDecStmt(n *ast.IncDecStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "IncDecStmt", name)
+	c.out.property("Tok", n.Tok.String())
+	c.pushClose()
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertIndexExpr(n *ast.IndexExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "IndexExpr", name)
+	c.pushClose()
+	c.push(n.Index, "Index")
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertIndexListExpr(n *ast.IndexListExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "IndexListExpr", name)
+	c.pushClose()
+	if len(n.Indices) > 0 {
+		c.pushClose()
+		for i := len(n.Indices) - 1; i >= 0; i-- {
+			c.push(n.Indices[i], "")
+		}
+		c.pushList("ListOfExpr", "Indices")
+	}
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertInterfaceType(n *ast.InterfaceType, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "InterfaceType", name)
+	c.out.property("Incomplete", n.Incomplete)
+	c.pushClose()
+	c.push(n.Methods, "Methods")
+}
+
+func (c *converter) convertKeyValueExpr(n *ast.KeyValueExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "KeyValueExpr", name)
+	c.pushClose()
+	c.push(n.Value, "Value")
+	c.push(n.Key, "Key")
+}
+
+func (c *converter) convertLabeledStmt(n *ast.LabeledStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "LabeledStmt", name)
+	c.pushClose()
+	c.push(n.Stmt, "Stmt")
+	c.push(n.Label, "Label")
+}
+
+func (c *converter) convertMapType(n *ast.MapType, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "MapType", name)
+	c.pushClose()
+	c.push(n.Value, "Value")
+	c.push(n.Key, "Key")
+}
+
+func (c *converter) convertParenExpr(n *ast.ParenExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ParenExpr", name)
+	c.pushClose()
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertRangeStmt(n *ast.RangeStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "RangeStmt", name)
+	c.out.property("Tok", n.Tok.String())
+	c.pushClose()
+	c.push(n.Body, "Body")
+	c.push(n.X, "X")
+	c.push(n.Value, "Value")
+	c.push(n.Key, "Key")
+}
+
+func (c *converter) convertReturnStmt(n *ast.ReturnStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ReturnStmt", name)
+	c.pushClose()
+	if len(n.Results) > 0 {
+		c.pushClose()
+		for i := len(n.Results) - 1; i >= 0; i-- {
+			c.push(n.Results[i], "")
+		}
+		c.pushList("ListOfExpr", "Results")
+	}
+}
+
+func (c *converter) convertSelectStmt(n *ast.SelectStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "SelectStmt", name)
+	c.pushClose()
+	c.push(n.Body, "Body")
+}
+
+func (c *converter) convertSelectorExpr(n *ast.SelectorExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "SelectorExpr", name)
+	c.pushClose()
+	c.push(n.Sel, "Sel")
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertSendStmt(n *ast.SendStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "SendStmt", name)
+	c.pushClose()
+	c.push(n.Value, "Value")
+	c.push(n.Chan, "Chan")
+}
+
+func (c *converter) convertSliceExpr(n *ast.SliceExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "SliceExpr", name)
+	c.out.property("Slice3", n.Slice3)
+	c.pushClose()
+	c.push(n.Max, "Max")
+	c.push(n.High, "High")
+	c.push(n.Low, "Low")
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertStarExpr(n *ast.StarExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "StarExpr", name)
+	c.pushClose()
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertStructType(n *ast.StructType, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "StructType", name)
+	c.out.property("Incomplete", n.Incomplete)
+	c.pushClose()
+	c.push(n.Fields, "Fields")
+}
+
+func (c *converter) convertSwitchStmt(n *ast.SwitchStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "SwitchStmt", name)
+	c.pushClose()
+	c.push(n.Body, "Body")
+	c.push(n.Tag, "Tag")
+	c.push(n.Init, "Init")
+}
+
+func (c *converter) convertTypeAssertExpr(n *ast.TypeAssertExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "TypeAssertExpr", name)
+	c.pushClose()
+	c.push(n.Type, "Type")
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertTypeSpec(n *ast.TypeSpec, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "TypeSpec", name)
+	if n.Assign.IsValid() {
+		c.out.property("Assign", int(c.position(n.Assign).offset))
+	}
+	c.pushClose()
+	c.push(n.Comment, "Comment")
+	c.push(n.Type, "Type")
+	c.push(n.TypeParams, "TypeParams")
+	c.push(n.Name, "Name")
+	c.push(n.Doc, "Doc")
+}
+
+func (c *converter) convertTypeSwitchStmt(n *ast.TypeSwitchStmt, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "TypeSwitchStmt", name)
+	c.pushClose()
+	c.push(n.Body, "Body")
+	c.push(n.Assign, "Assign")
+	c.push(n.Init, "Init")
+}
+
+func (c *converter) convertUnaryExpr(n *ast.UnaryExpr, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "UnaryExpr", name)
+	c.out.property("Op", n.Op.String())
+	c.pushClose()
+	c.push(n.X, "X")
+}
+
+func (c *converter) convertValueSpec(n *ast.ValueSpec, name string) {
+	if n == nil {
+		return
+	}
+	c.open(n, "ValueSpec", name)
+	c.pushClose()
+	c.push(n.Comment, "Comment")
+	if len(n.Values) > 0 {
+		c.pushClose()
+		for i := len(n.Values) - 1; i >= 0; i-- {
+			c.push(n.Values[i], "")
+		}
+		c.pushList("ListOfExpr", "Values")
+	}
+	c.push(n.Type, "Type")
+	if len(n.Names) > 0 {
+		c.pushClose()
+		for i := len(n.Names) - 1; i >= 0; i-- {
+			c.push(n.Names[i], "")
+		}
+		c.pushList("ListOfIdent", "Names")
+	}
+	c.push(n.Doc, "Doc")
+}
+
+// children calls f with each of the children of n, in order.
+func children(n ast.Node, f func(ast.Node)) {
+	switch n := n.(type) {
+	case *ast.ArrayType:
+		if n.Len != nil {
+			f(n.Len)
+		}
+		if n.Elt != nil {
+			f(n.Elt)
+		}
+	case *ast.AssignStmt:
+		for _, e := range n.Lhs {
+			if e != nil {
+				f(e)
+			}
+		}
+		for _, e := range n.Rhs {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.BadDecl:
+	case *ast.BadExpr:
+	case *ast.BadStmt:
+	case *ast.BasicLit:
+	case *ast.BinaryExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+		if n.Y != nil {
+			f(n.Y)
+		}
+	case *ast.BlockStmt:
+		for _, e := range n.List {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.BranchStmt:
+		if n.Label != nil {
+			f(n.Label)
+		}
+	case *ast.CallExpr:
+		if n.Fun != nil {
+			f(n.Fun)
+		}
+		for _, e := range n.Args {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.CaseClause:
+		for _, e := range n.List {
+			if e != nil {
+				f(e)
+			}
+		}
+		for _, e := range n.Body {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.ChanType:
+		if n.Value != nil {
+			f(n.Value)
+		}
+	case *ast.CommClause:
+		if n.Comm != nil {
+			f(n.Comm)
+		}
+		for _, e := range n.Body {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.Comment:
+	case *ast.CommentGroup:
+		for _, e := range n.List {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.CompositeLit:
+		if n.Type != nil {
+			f(n.Type)
+		}
+		for _, e := range n.Elts {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.DeclStmt:
+		if n.Decl != nil {
+			f(n.Decl)
+		}
+	case *ast.DeferStmt:
+		if n.Call != nil {
+			f(n.Call)
+		}
+	case *ast.Ellipsis:
+		if n.Elt != nil {
+			f(n.Elt)
+		}
+	case *ast.EmptyStmt:
+	case *ast.ExprStmt:
+		if n.X != nil {
+			f(n.X)
+		}
+	case *ast.Field:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		for _, e := range n.Names {
+			if e != nil {
+				f(e)
+			}
+		}
+		if n.Type != nil {
+			f(n.Type)
+		}
+		if n.Tag != nil {
+			f(n.Tag)
+		}
+		if n.Comment != nil {
+			f(n.Comment)
+		}
+	case *ast.FieldList:
+		for _, e := range n.List {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.File:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		if n.Name != nil {
+			f(n.Name)
+		}
+		for _, e := range n.Decls {
+			if e != nil {
+				f(e)
+			}
+		}
+		for _, e := range n.Unresolved {
+			if e != nil {
+				f(e)
+			}
+		}
+		for _, e := range n.Comments {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.ForStmt:
+		if n.Init != nil {
+			f(n.Init)
+		}
+		if n.Cond != nil {
+			f(n.Cond)
+		}
+		if n.Post != nil {
+			f(n.Post)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.FuncDecl:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		if n.Recv != nil {
+			f(n.Recv)
+		}
+		if n.Name != nil {
+			f(n.Name)
+		}
+		if n.Type != nil {
+			f(n.Type)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.FuncLit:
+		if n.Type != nil {
+			f(n.Type)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.FuncType:
+		if n.TypeParams != nil {
+			f(n.TypeParams)
+		}
+		if n.Params != nil {
+			f(n.Params)
+		}
+		if n.Results != nil {
+			f(n.Results)
+		}
+	case *ast.GenDecl:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		for _, e := range n.Specs {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.GoStmt:
+		if n.Call != nil {
+			f(n.Call)
+		}
+	case *ast.Ident:
+	case *ast.IfStmt:
+		if n.Init != nil {
+			f(n.Init)
+		}
+		if n.Cond != nil {
+			f(n.Cond)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+		if n.Else != nil {
+			f(n.Else)
+		}
+	case *ast.ImportSpec:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		if n.Name != nil {
+			f(n.Name)
+		}
+		if n.Path != nil {
+			f(n.Path)
+		}
+		if n.Comment != nil {
+			f(n.Comment)
+		}
+	case *ast.IncDecStmt:
+		if n.X != nil {
+			f(n.X)
+		}
+	case *ast.IndexExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+		if n.Index != nil {
+			f(n.Index)
+		}
+	case *ast.IndexListExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+		for _, e := range n.Indices {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.InterfaceType:
+		if n.Methods != nil {
+			f(n.Methods)
+		}
+	case *ast.KeyValueExpr:
+		if n.Key != nil {
+			f(n.Key)
+		}
+		if n.Value != nil {
+			f(n.Value)
+		}
+	case *ast.LabeledStmt:
+		if n.Label != nil {
+			f(n.Label)
+		}
+		if n.Stmt != nil {
+			f(n.Stmt)
+		}
+	case *ast.MapType:
+		if n.Key != nil {
+			f(n.Key)
+		}
+		if n.Value != nil {
+			f(n.Value)
+		}
+	case *ast.ParenExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+	case *ast.RangeStmt:
+		if n.Key != nil {
+			f(n.Key)
+		}
+		if n.Value != nil {
+			f(n.Value)
+		}
+		if n.X != nil {
+			f(n.X)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.ReturnStmt:
+		for _, e := range n.Results {
+			if e != nil {
+				f(e)
+			}
+		}
+	case *ast.SelectStmt:
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.SelectorExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+		if n.Sel != nil {
+			f(n.Sel)
+		}
+	case *ast.SendStmt:
+		if n.Chan != nil {
+			f(n.Chan)
+		}
+		if n.Value != nil {
+			f(n.Value)
+		}
+	case *ast.SliceExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+		if n.Low != nil {
+			f(n.Low)
+		}
+		if n.High != nil {
+			f(n.High)
+		}
+		if n.Max != nil {
+			f(n.Max)
+		}
+	case *ast.StarExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+	case *ast.StructType:
+		if n.Fields != nil {
+			f(n.Fields)
+		}
+	case *ast.SwitchStmt:
+		if n.Init != nil {
+			f(n.Init)
+		}
+		if n.Tag != nil {
+			f(n.Tag)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.TypeAssertExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+		if n.Type != nil {
+			f(n.Type)
+		}
+	case *ast.TypeSpec:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		if n.Name != nil {
+			f(n.Name)
+		}
+		if n.TypeParams != nil {
+			f(n.TypeParams)
+		}
+		if n.Type != nil {
+			f(n.Type)
+		}
+		if n.Comment != nil {
+			f(n.Comment)
+		}
+	case *ast.TypeSwitchStmt:
+		if n.Init != nil {
+			f(n.Init)
+		}
+		if n.Assign != nil {
+			f(n.Assign)
+		}
+		if n.Body != nil {
+			f(n.Body)
+		}
+	case *ast.UnaryExpr:
+		if n.X != nil {
+			f(n.X)
+		}
+	case *ast.ValueSpec:
+		if n.Doc != nil {
+			f(n.Doc)
+		}
+		for _, e := range n.Names {
+			if e != nil {
+				f(e)
+			}
+		}
+		if n.Type != nil {
+			f(n.Type)
+		}
+		for _, e := range n.Values {
+			if e != nil {
+				f(e)
+			}
+		}
+		if n.Comment != nil {
+			f(n.Comment)
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// reflectTree converts n the way the driver used to before the converters
+// were generated, walking the fields of the nodes through reflection. It's
+// kept as the reference the generated code is checked against.
+func reflectTree(c *converter, n ast.Node) *node {
+	v := reflect.ValueOf(n)
+	if v.IsNil() {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	start, end := c.position(n.Pos()), c.position(n.End())
+	root := &node{
+		InternalType: t.Name(),
+		StartOffset:  start.offset,
+		EndOffset:    end.offset,
+		StartLine:    start.line,
+		StartColumn:  start.column,
+		EndLine:      end.line,
+		EndColumn:    end.column,
+		Properties:   make(map[string]interface{}),
+	}
+	c.out = propertySink(root.Properties)
+	c.addRefs(n)
+	if c.info != nil {
+		c.addTypes(n)
+	}
+	switch n := n.(type) {
+	case *ast.File:
+		addFileInfo(n, c.filename, c.out)
+	case *ast.BasicLit:
+		addLiteral(n, c.out)
+	case *ast.SelectorExpr:
+		c.addCgoRef(n)
+	}
+
+	// The children that don't come from fields are written first.
+	extra := &nodeSink{}
+	c.out = extra
+	switch n := n.(type) {
+	case *ast.Comment:
+		c.directive(n)
+	case *ast.ImportSpec:
+		c.cgoPreamble(n)
+	}
+	if extra.root != nil {
+		root.Children = append(root.Children, extra.root)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if ignoredFields[name] {
+			continue
+		}
+		field := v.Field(i)
+		value := field.Interface()
+
+		switch v := value.(type) {
+		case ast.Node:
+			if child := reflectTree(c, v.(ast.Node)); child != nil {
+				child.InternalName = name
+				if lit, ok := v.(*ast.BasicLit); ok {
+					reflectStructTag(c, child, lit)
+				}
+				root.Children = append(root.Children, child)
+			}
+			continue
+		case token.Pos:
+			if offsetFields[t.Name()+"."+name] && v.IsValid() {
+				root.Properties[name] = int(c.position(v).offset)
+			}
+			continue
+		case nil:
+			continue
+		default:
+		}
+
+		switch field.Kind() {
+		case reflect.Slice:
+			if field.Len() == 0 {
+				continue
+			}
+			slice := &node{InternalName: name, InternalType: listTypeName(field)}
+			for i := 0; i < field.Len(); i++ {
+				e := field.Index(i).Interface()
+				if n, ok := e.(ast.Node); ok {
+					if child := reflectTree(c, n); child != nil {
+						slice.Children = append(slice.Children, child)
+					}
+				} else {
+					panic(fmt.Sprintf("found slice of non nodes: %T", e))
+				}
+			}
+			root.Children = append(root.Children, slice)
+
+		case reflect.String:
+			if field.Len() > 0 {
+				root.Properties[name] = field.String()
+			}
+
+		case reflect.Bool:
+			root.Properties[name] = field.Bool()
+
+		default:
+			if s, ok := value.(fmt.Stringer); ok {
+				root.Properties[name] = s.String()
+			} else {
+				root.Properties[name] = int(field.Int())
+			}
+		}
+	}
+
+	if len(root.Properties) == 0 {
+		root.Properties = nil
+	}
+	return root
+}
+
+// reflectStructTag adds the keys of lit, as named in n, to n.
+func reflectStructTag(c *converter, n *node, lit *ast.BasicLit) {
+	s := &nodeSink{}
+	s.openNode(n.InternalType, n.InternalName, position{}, position{})
+	c.out = s
+	c.structTag(lit, n.InternalName)
+	for k, v := range s.root.Properties {
+		if n.Properties == nil {
+			n.Properties = make(map[string]interface{})
+		}
+		n.Properties[k] = v
+	}
+	n.Children = append(n.Children, s.root.Children...)
+}
+
+func listTypeName(v reflect.Value) string {
+	t := v.Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return "ListOf" + t.Name()
+}
+
+var offsetFields = map[string]bool{
+	"CallExpr.Ellipsis": true,
+	"GenDecl.Lparen":    true,
+	"TypeSpec.Assign":   true,
+}
+
+var ignoredFields = map[string]bool{
+	"Imports": true,
+	"Scope":   true,
+	"Obj":     true,
+}
+
+// propertySink keeps the properties it receives, ignoring the nodes.
+type propertySink map[string]interface{}
+
+func (s propertySink) openNode(typ, name string, start, end position) {}
+func (s propertySink) property(key string, value interface{})         { s[key] = value }
+func (s propertySink) closeNode()                                     {}
+
+// corpusFile is a file of the corpus, already parsed.
+type corpusFile struct {
+	fs  *token.FileSet
+	f   *ast.File
+	ids map[ast.Node]int
+}
+
+// corpus parses the Go files found under the given directories of GOROOT,
+// testdata excluded.
+func corpus(tb testing.TB, dirs ...string) []*corpusFile {
+	var files []*corpusFile
+	for _, dir := range dirs {
+		root := filepath.Join(build.Default.GOROOT, "src", dir)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && info.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+
+			fs := token.NewFileSet()
+			f, err := parser.ParseFile(fs, path, nil, parser.ParseComments)
+			if err != nil {
+				return nil
+			}
+			f.Comments = freeComments(f)
+			files = append(files, &corpusFile{fs: fs, f: f, ids: declIDs(f)})
+			return nil
+		})
+		if err != nil {
+			tb.Skipf("could not read the corpus: %v", err)
+		}
+	}
+	if len(files) == 0 {
+		tb.Skip("no files in the corpus")
+	}
+	return files
+}
+
+func (cf *corpusFile) convert(s sink) {
+	newConverter(s, cf.fs, cf.f, cf.ids).file(cf.f)
+}
+
+func (cf *corpusFile) reflectTree() *node {
+	return reflectTree(newConverter(nil, cf.fs, cf.f, cf.ids), cf.f)
+}
+
+func TestConvertMatchesReflection(t *testing.T) {
+	for _, cf := range corpus(t, "go") {
+		want := cf.reflectTree()
+
+		s := &nodeSink{}
+		cf.convert(s)
+		if !cmp.Equal(want, s.root) {
+			t.Fatalf("different trees for %s: %s", cf.fs.File(cf.f.Pos()).Name(), cmp.Diff(want, s.root))
+		}
+
+		wantJSON, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		js := newJSONSink(&buf)
+		cf.convert(js)
+		if err := js.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(wantJSON, buf.Bytes()) {
+			t.Fatalf("different JSON for %s", cf.fs.File(cf.f.Pos()).Name())
+		}
+	}
+}
+
+func TestAppendString(t *testing.T) {
+	for _, s := range []string{
+		"", "plain", `"quoted" \ back`, "<a href='x'>&amp;</a>",
+		"\x00\x01\b\f\n\r\t\x1f\x7f", "héllo, 世界", "  ", "bad \xff utf8",
+	} {
+		want, _ := json.Marshal(s)
+		if got := appendString(nil, s); !bytes.Equal(got, want) {
+			t.Errorf("appendString(%q) = %s; want %s", s, got, want)
+		}
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	for _, req := range []*request{
+		{Content: "package main\n\nfunc main() { println(\"<hello>\") }"},
+		{ID: "42", Content: "package main"},
+		{Content: "package main // <main>", Output: "both"},
+		{Content: "package p\n\nvar a = )"},
+		{Content: ""},
+		{Action: "print", Tree: json.RawMessage(`{"InternalType": "Ident", "Properties": {"Name": "<a>"}}`)},
+		{Action: "format", Content: "package p\n"},
+		{Action: "format", Content: "package p\nvar a = []int{ 1 }"},
+		{Files: []*sourceFile{
+			{Name: "a.go", Content: "package p\n\nimport \"fmt\""},
+			{Name: "b.go", Content: "package p\n\nfunc f() { fmt.Println() }"},
+		}},
+	} {
+		var want bytes.Buffer
+		if err := json.NewEncoder(&want).Encode(handle(req)); err != nil {
+			t.Fatal(err)
+		}
+
+		var got bytes.Buffer
+		if err := writeResponse(bufio.NewWriter(&got), handle(req)); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("different responses:\n%s\n%s", got.String(), want.String())
+		}
+	}
+}
+
+// benchmarkCorpus holds the stdlib packages the conversion is measured on.
+var benchmarkCorpus = []string{"go", "net/http", "runtime"}
+
+func BenchmarkReflectTree(b *testing.B) {
+	files := corpus(b, benchmarkCorpus...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, cf := range files {
+			if err := json.NewEncoder(io.Discard).Encode(cf.reflectTree()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkConvertTree(b *testing.B) {
+	files := corpus(b, benchmarkCorpus...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, cf := range files {
+			s := &nodeSink{}
+			cf.convert(s)
+		}
+	}
+}
+
+func BenchmarkConvertJSON(b *testing.B) {
+	files := corpus(b, benchmarkCorpus...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, cf := range files {
+			s := newJSONSink(io.Discard)
+			cf.convert(s)
+			if err := s.flush(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// deepFile returns a file declaring x with the given value, that can be
+// nested far deeper than the parser allows.
+func deepFile(t *testing.T, value ast.Expr) *corpusFile {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, "", "package p\n\nvar x = 0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0] = value
+	return &corpusFile{fs: fs, f: f, ids: declIDs(f)}
+}
+
+// countSink counts the nodes it receives and how deep they go.
+type countSink struct {
+	nodes, depth, maxDepth int
+}
+
+func (s *countSink) openNode(typ, name string, start, end position) {
+	s.nodes++
+	s.depth++
+	if s.depth > s.maxDepth {
+		s.maxDepth = s.depth
+	}
+}
+func (s *countSink) property(key string, value interface{}) {}
+func (s *countSink) closeNode()                             { s.depth-- }
+
+func TestDeepTrees(t *testing.T) {
+	// Converting these trees recursively would need far more stack than
+	// this, crashing the tests.
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	// Twice as deep as the parser allows.
+	const depth = 200000
+	var concat, parens, lits ast.Expr = &ast.BasicLit{Kind: token.STRING, Value: `"a"`}, &ast.Ident{Name: "a"}, &ast.CompositeLit{}
+	for i := 0; i < depth; i++ {
+		concat = &ast.BinaryExpr{X: concat, Op: token.ADD, Y: &ast.BasicLit{Kind: token.STRING, Value: `"a"`}}
+		parens = &ast.ParenExpr{X: parens}
+		lits = &ast.CompositeLit{Elts: []ast.Expr{lits}}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		value ast.Expr
+		depth int
+	}{
+		{"concatenation", concat, depth},
+		{"parentheses", parens, depth},
+		{"composite literals", lits, 2 * depth},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cf := deepFile(t, tc.value)
+
+			s := &countSink{}
+			cf.convert(s)
+			// File, ListOfDecl, GenDecl, ListOfSpec, ValueSpec and
+			// ListOfExpr hold the value.
+			if want := tc.depth + 7; s.maxDepth != want {
+				t.Fatalf("expected depth %d; got %d", want, s.maxDepth)
+			}
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			js := newJSONSink(io.Discard)
+			cf.convert(js)
+			if err := js.flush(); err != nil {
+				t.Fatal(err)
+			}
+			runtime.ReadMemStats(&after)
+			// Only the pending nodes are kept in memory, so it grows with
+			// the depth of the tree but not with the size of the output.
+			if perLevel := (after.TotalAlloc - before.TotalAlloc) / uint64(tc.depth); perLevel > 4<<10 {
+				t.Fatalf("expected less than 4KB per level; got %d bytes", perLevel)
+			}
+		})
+	}
+}
+
+func TestDeepTreeLimit(t *testing.T) {
+	var x ast.Expr = &ast.Ident{Name: "a"}
+	for i := 0; i < 100000; i++ {
+		x = &ast.ParenExpr{X: x}
+	}
+	cf := deepFile(t, x)
+
+	res := safely("", func() *response {
+		render(cf.convert, limits{maxDepth: 1000})
+		return &response{}
+	})
+	if res.Code != codeTooDeep {
+		t.Fatalf("expected code %s; got %q", codeTooDeep, res.Code)
+	}
+}
+
+func TestDeepConcatenation(t *testing.T) {
+	// Bindata files concatenate huge numbers of strings, up to the nesting
+	// limit of the parser. The parser itself resolves them recursively, so
+	// the stack is not limited here.
+	content := `package p
+
+var data = "a"` + strings.Repeat(` + "a"`, 90000)
+	res := handle(&request{Content: content})
+	if res.Status != "ok" {
+		t.Fatalf("expected status ok; got %s: %v", res.Status, res.Errors)
+	}
+}
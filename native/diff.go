@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each hunk of a diff.
+const diffContext = 3
+
+// unifiedDiff returns the changes from old to new in the unified format,
+// the same as gofmt -d writes them, or "" if there are none.
+//
+// Like gofmt, it computes an anchored diff: lines found once in both texts
+// are matched first, and the longest run of them in the same order anchors
+// the rest. It runs in O(n log n) time, where a minimal diff can take
+// quadratic time, and keeps unrelated braces or blank lines from being
+// matched together.
+func unifiedDiff(oldName, old, newName, new string) string {
+	if old == new {
+		return ""
+	}
+	x, y := diffLines(old), diffLines(new)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff %s %s\n--- %s\n+++ %s\n", oldName, newName, oldName, newName)
+
+	var (
+		// done is how far the lines have been written, from start, the
+		// first line of the current hunk, which is held in hunk.
+		done, start linePair
+		hunk        []string
+		// removed and added count the lines of each text in the hunk.
+		removed, added int
+	)
+	for _, m := range diffAnchors(x, y) {
+		if m.x < done.x {
+			// It's within the lines already matched.
+			continue
+		}
+
+		// Grow the match around the anchor as far as lines are equal.
+		from, to := m, m
+		for from.x > done.x && from.y > done.y && x[from.x-1] == y[from.y-1] {
+			from.x--
+			from.y--
+		}
+		for to.x < len(x) && to.y < len(y) && x[to.x] == y[to.y] {
+			to.x++
+			to.y++
+		}
+
+		for _, l := range x[done.x:from.x] {
+			hunk = append(hunk, "-"+l)
+			removed++
+		}
+		for _, l := range y[done.y:from.y] {
+			hunk = append(hunk, "+"+l)
+			added++
+		}
+
+		same := to.x - from.x
+		last := to.x == len(x) && to.y == len(y)
+		if !last && (same < diffContext || len(hunk) > 0 && same < 2*diffContext) {
+			// Too few lines in common to split the hunk.
+			for _, l := range x[from.x:to.x] {
+				hunk = append(hunk, " "+l)
+			}
+			removed += same
+			added += same
+			done = to
+			continue
+		}
+
+		if len(hunk) > 0 {
+			n := same
+			if n > diffContext {
+				n = diffContext
+			}
+			for _, l := range x[from.x : from.x+n] {
+				hunk = append(hunk, " "+l)
+			}
+			removed += n
+			added += n
+
+			// Lines are numbered from 1, but empty ranges are written
+			// as 0,0.
+			if removed > 0 {
+				start.x++
+			}
+			if added > 0 {
+				start.y++
+			}
+			fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", start.x, removed, start.y, added)
+			for _, l := range hunk {
+				b.WriteString(l)
+			}
+			hunk, removed, added = hunk[:0], 0, 0
+		}
+		if last {
+			break
+		}
+
+		// Start the next hunk with the context before its changes.
+		start = linePair{to.x - diffContext, to.y - diffContext}
+		for _, l := range x[start.x:to.x] {
+			hunk = append(hunk, " "+l)
+		}
+		removed += diffContext
+		added += diffContext
+		done = to
+	}
+	return b.String()
+}
+
+// linePair is a pair of line indexes, one in each text of a diff.
+type linePair struct{ x, y int }
+
+// diffLines splits s into lines, each with its newline. A missing newline
+// at the end of s is reported as diff does.
+func diffLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// diffAnchors returns the longest sequence of pairs of lines found once in
+// both x and y, in the same order in both. It's surrounded by {0, 0} and
+// {len(x), len(y)}, so that the matches before the first anchor and after
+// the last one are found like the rest.
+func diffAnchors(x, y []string) []linePair {
+	// count holds how many times each line appears in x and y, up to 2.
+	type count struct{ x, y, yi int }
+	counts := make(map[string]*count)
+	for _, l := range x {
+		c := counts[l]
+		if c == nil {
+			c = &count{}
+			counts[l] = c
+		}
+		if c.x < 2 {
+			c.x++
+		}
+	}
+	for i, l := range y {
+		if c := counts[l]; c != nil && c.y < 2 {
+			c.y++
+			c.yi = i
+		}
+	}
+
+	// The unique lines of x, in order, with their index in y.
+	var unique []linePair
+	for i, l := range x {
+		if c := counts[l]; c.x == 1 && c.y == 1 {
+			unique = append(unique, linePair{i, c.yi})
+		}
+	}
+
+	// The longest increasing sequence of their indexes in y is found by
+	// patience sorting: tops holds the last pair of each pile, which are
+	// sorted, and prev links each pair to the top of the pile before its
+	// own when it was added.
+	var tops []int
+	prev := make([]int, len(unique))
+	for i, p := range unique {
+		k := sort.Search(len(tops), func(k int) bool { return unique[tops[k]].y > p.y })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tops[k-1]
+		}
+		if k == len(tops) {
+			tops = append(tops, i)
+		} else {
+			tops[k] = i
+		}
+	}
+
+	anchors := make([]linePair, len(tops)+2)
+	anchors[len(anchors)-1] = linePair{len(x), len(y)}
+	if len(tops) > 0 {
+		for i, k := tops[len(tops)-1], len(tops); i >= 0; i, k = prev[i], k-1 {
+			anchors[k] = unique[i]
+		}
+	}
+	return anchors
+}
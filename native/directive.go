@@ -0,0 +1,273 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// plainDirectives are the directives written without a tool, as //line or
+// //export are, instead of as //tool:name.
+var plainDirectives = []string{"line", "export", "extern"}
+
+// directiveComment is a directive comment, such as
+//
+//	//go:embed static/*.html
+//
+// whose Tool is go, Name embed and Args static/*.html, which start at
+// argsPos. Args has no leading or trailing spaces.
+//
+// The Args of //nolint, as in
+//
+//	//nolint:errcheck,unused // closed by the caller
+//
+// are the list of linters after the colon, errcheck,unused, without the
+// explanation that may follow.
+type directiveComment struct {
+	tool, name, args string
+	slash, argsPos   token.Pos
+}
+
+func (d directiveComment) end() token.Pos {
+	return d.argsPos + token.Pos(len(d.args))
+}
+
+// parseDirective parses c as a directive comment, such as //go:embed or
+// //nolint, or returns false if it's not one. Tools and names are written
+// as go/ast and the go command expect them: //tool:name, where the tool and
+// the first letter of the name are lowercase letters and digits.
+func parseDirective(c *ast.Comment) (directiveComment, bool) {
+	text, ok := strings.CutPrefix(c.Text, "//")
+	if !ok || !lowerAlnum(text[:min(1, len(text))]) {
+		return directiveComment{}, false
+	}
+
+	if rest, ok := strings.CutPrefix(text, "nolint"); ok && (rest == "" || rest[0] == ':' || rest[0] == ' ' || rest[0] == '\t') {
+		d := directiveComment{name: "nolint", slash: c.Slash, argsPos: c.Slash + token.Pos(len("//nolint"))}
+		if list, ok := strings.CutPrefix(rest, ":"); ok {
+			if i := strings.IndexFunc(list, unicode.IsSpace); i >= 0 {
+				list = list[:i]
+			}
+			d.args, d.argsPos = list, d.argsPos+1
+		}
+		return d, true
+	}
+
+	var d directiveComment
+	colon := strings.IndexByte(text, ':')
+	if colon > 0 && colon+1 < len(text) && lowerAlnum(text[:colon]) && lowerAlnum(text[colon+1:colon+2]) {
+		d.tool = text[:colon]
+		text = text[colon+1:]
+		if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+			d.name, text = text[:i], text[i:]
+		} else {
+			d.name, text = text, ""
+		}
+	} else {
+		for _, name := range plainDirectives {
+			if rest, ok := strings.CutPrefix(text, name); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+				d.name, text = name, rest
+				break
+			}
+		}
+		if d.name == "" {
+			return directiveComment{}, false
+		}
+	}
+
+	args := strings.TrimLeftFunc(text, unicode.IsSpace)
+	d.args = strings.TrimRightFunc(args, unicode.IsSpace)
+	d.slash = c.Slash
+	d.argsPos = c.End() - token.Pos(len(args))
+	return d, true
+}
+
+// lowerAlnum returns whether s is made of lowercase letters and digits only.
+func lowerAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if b := s[i]; (b < 'a' || b > 'z') && (b < '0' || b > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitArgs splits the arguments of d: the linters of //nolint at commas,
+// and those of the other directives with splitDirectiveArgs.
+func (d directiveComment) splitArgs() ([]directiveArg, bool) {
+	if d.tool != "" || d.name != "nolint" {
+		return splitDirectiveArgs(d.args)
+	}
+
+	var args []directiveArg
+	start := 0
+	for _, linter := range strings.Split(d.args, ",") {
+		if linter != "" {
+			args = append(args, directiveArg{value: linter, start: start, end: start + len(linter)})
+		}
+		start += len(linter) + 1
+	}
+	return args, true
+}
+
+// directiveArg is an argument of a directive comment, at offsets start to
+// end of its arguments.
+type directiveArg struct {
+	value      string
+	start, end int
+}
+
+// splitDirectiveArgs splits the arguments of a directive comment as go/ast
+// and the go command do: at spaces, into bare words and double or back
+// quoted Go strings, which are unquoted. It returns false if a quoted
+// string is malformed or not followed by a space.
+func splitDirectiveArgs(s string) ([]directiveArg, bool) {
+	var args []directiveArg
+	for i := 0; ; {
+		rest := strings.TrimLeftFunc(s[i:], unicode.IsSpace)
+		i = len(s) - len(rest)
+		if rest == "" {
+			return args, true
+		}
+
+		a := directiveArg{start: i}
+		if rest[0] == '"' || rest[0] == '`' {
+			q, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, false
+			}
+			if r, _ := utf8.DecodeRuneInString(rest[len(q):]); len(q) < len(rest) && !unicode.IsSpace(r) {
+				return nil, false
+			}
+			a.value, _ = strconv.Unquote(q)
+			a.end = i + len(q)
+		} else {
+			n := strings.IndexFunc(rest, unicode.IsSpace)
+			if n < 0 {
+				n = len(rest)
+			}
+			a.value, a.end = rest[:n], i+n
+		}
+		args = append(args, a)
+		i = a.end
+	}
+}
+
+// directiveTargets returns the declarations, specs and fields the
+// directives of f attach to, by the comments holding them. A directive
+// attaches to the declaration it documents, or to the spec or field it's
+// written next to. The doc of a declaration of a single spec attaches to
+// the spec, so that //go:embed finds the variable it fills.
+func directiveTargets(f *ast.File) map[*ast.Comment]ast.Node {
+	targets := make(map[*ast.Comment]ast.Node)
+	add := func(cg *ast.CommentGroup, n ast.Node) {
+		if cg == nil {
+			return
+		}
+		for _, c := range cg.List {
+			if _, ok := parseDirective(c); ok {
+				targets[c] = n
+			}
+		}
+	}
+
+	for _, d := range f.Decls {
+		inspect(d, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				add(n.Doc, n)
+			case *ast.GenDecl:
+				if len(n.Specs) == 1 && !n.Lparen.IsValid() {
+					add(n.Doc, n.Specs[0])
+				} else {
+					add(n.Doc, n)
+				}
+			case *ast.ImportSpec:
+				add(n.Doc, n)
+				add(n.Comment, n)
+			case *ast.ValueSpec:
+				add(n.Doc, n)
+				add(n.Comment, n)
+			case *ast.TypeSpec:
+				add(n.Doc, n)
+				add(n.Comment, n)
+			case *ast.Field:
+				add(n.Doc, n)
+				add(n.Comment, n)
+			}
+			return true
+		})
+	}
+	return targets
+}
+
+// targetName returns the name of what the directive target n declares, or
+// "" if it has none.
+func targetName(n ast.Node) string {
+	var names []*ast.Ident
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		names = []*ast.Ident{n.Name}
+	case *ast.TypeSpec:
+		names = []*ast.Ident{n.Name}
+	case *ast.ImportSpec:
+		if n.Name != nil {
+			names = []*ast.Ident{n.Name}
+		}
+	case *ast.ValueSpec:
+		names = n.Names
+	case *ast.Field:
+		names = n.Names
+	}
+
+	s := make([]string, len(names))
+	for i, id := range names {
+		s[i] = id.Name
+	}
+	return strings.Join(s, ", ")
+}
+
+// directive writes the Directive node of comment, if it's a directive
+// comment. Its arguments are split by splitArgs into DirectiveArg nodes,
+// with their unquoted text as Value, unless they're malformed. Its Target
+// and TargetRef are the name and NodeID of the declaration it attaches to.
+func (c *converter) directive(comment *ast.Comment) {
+	d, ok := parseDirective(comment)
+	if !ok {
+		return
+	}
+
+	c.out.openNode("Directive", "Directive", c.position(d.slash), c.position(d.end()))
+	if d.tool != "" {
+		c.out.property("Tool", d.tool)
+	}
+	c.out.property("Name", d.name)
+	if d.args != "" {
+		c.out.property("Args", d.args)
+	}
+	if t, ok := c.targets[comment]; ok {
+		if name := targetName(t); name != "" {
+			c.out.property("Target", name)
+		}
+		if id, ok := c.ids[t]; ok {
+			c.out.property("TargetRef", id)
+		}
+	}
+
+	if args, ok := d.splitArgs(); ok && len(args) > 0 {
+		c.out.openNode("ListOfDirectiveArg", "ArgList", position{}, position{})
+		for _, a := range args {
+			c.out.openNode("DirectiveArg", "", c.position(d.argsPos+token.Pos(a.start)), c.position(d.argsPos+token.Pos(a.end)))
+			c.out.property("Value", a.value)
+			c.out.closeNode()
+		}
+		c.out.closeNode()
+	}
+	c.out.closeNode()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDirectives = `//go:build linux && !cgo
+
+//go:generate stringer -type Op "-trimprefix=Op "
+package p
+
+import (
+	"embed"
+	_ "unsafe"
+)
+
+//go:embed static/*.html "a b.txt"
+var static embed.FS
+
+//go:linkname now runtime.nanotime
+//go:noinline
+func now() int64
+
+type T struct {
+	X int //nolint:errcheck,unused // kept for the layout
+}
+
+func f() {
+	g() //nolint
+}
+`
+
+func TestDirectives(t *testing.T) {
+	res, err := parse(&request{Content: testDirectives})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tt := []struct {
+		tool, name, args, target string
+		values                   []string
+	}{
+		{"go", "build", "linux && !cgo", "", []string{"linux", "&&", "!cgo"}},
+		{"go", "generate", `stringer -type Op "-trimprefix=Op "`, "", []string{"stringer", "-type", "Op", "-trimprefix=Op "}},
+		{"go", "embed", `static/*.html "a b.txt"`, "static", []string{"static/*.html", "a b.txt"}},
+		{"go", "linkname", "now runtime.nanotime", "now", []string{"now", "runtime.nanotime"}},
+		{"go", "noinline", "", "now", nil},
+		{"", "nolint", "errcheck,unused", "X", []string{"errcheck", "unused"}},
+		{"", "nolint", "", "", nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// The two nolint directives only differ in their targets.
+			var d *node
+			for _, n := range findAll(res, "Directive") {
+				if target, _ := n.Properties["Target"].(string); n.Properties["Name"] == tc.name && target == tc.target {
+					d = n
+				}
+			}
+			if d == nil {
+				t.Fatalf("directive not found")
+			}
+			tool, _ := d.Properties["Tool"].(string)
+			args, _ := d.Properties["Args"].(string)
+			target, _ := d.Properties["Target"].(string)
+			if tool != tc.tool || args != tc.args || target != tc.target {
+				t.Errorf("expected %q, %q and %q; got %+v", tc.tool, tc.args, tc.target, d.Properties)
+			}
+
+			var values []string
+			if len(d.Children) > 0 {
+				for _, a := range d.Children[0].Children {
+					values = append(values, a.Properties["Value"].(string))
+				}
+			}
+			if strings.Join(values, "|") != strings.Join(tc.values, "|") {
+				t.Errorf("expected the arguments %q; got %q", tc.values, values)
+			}
+		})
+	}
+}
+
+func TestDirectiveTarget(t *testing.T) {
+	res, err := parse(&request{Content: testDirectives})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := find(res, "Directive", "Name", "embed")
+	spec := find(res, "ValueSpec", "", nil)
+	if d.Properties["TargetRef"] == nil || d.Properties["TargetRef"] != spec.Properties["NodeID"] {
+		t.Errorf("expected the directive to point to the spec; got %v and %v", d.Properties["TargetRef"], spec.Properties["NodeID"])
+	}
+
+	d = find(res, "Directive", "Name", "noinline")
+	fn := find(res, "FuncDecl", "", nil)
+	if d.Properties["TargetRef"] == nil || d.Properties["TargetRef"] != fn.Properties["NodeID"] {
+		t.Errorf("expected the directive to point to the function; got %v and %v", d.Properties["TargetRef"], fn.Properties["NodeID"])
+	}
+}
+
+func TestDirectivePositions(t *testing.T) {
+	res, err := parse(&request{Content: testDirectives})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := find(res, "Directive", "Name", "embed")
+	want := strings.Index(testDirectives, "//go:embed")
+	if int(d.StartOffset) != want || int(d.EndOffset) != want+len(`//go:embed static/*.html "a b.txt"`) {
+		t.Errorf("unexpected directive span %d-%d", d.StartOffset, d.EndOffset)
+	}
+	a := d.Children[0].Children[1]
+	if want := strings.Index(testDirectives, `"a b.txt"`); int(a.StartOffset) != want || int(a.EndOffset) != want+9 || a.StartColumn != 26 {
+		t.Errorf("unexpected argument position: %+v", a)
+	}
+}
+
+func TestNolintPositions(t *testing.T) {
+	res, err := parse(&request{Content: testDirectives})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := find(res, "Directive", "Args", "errcheck,unused")
+	want := strings.Index(testDirectives, "//nolint:")
+	if int(d.StartOffset) != want || int(d.EndOffset) != want+len("//nolint:errcheck,unused") {
+		t.Errorf("expected the directive to leave out the explanation; got %d-%d", d.StartOffset, d.EndOffset)
+	}
+	a := d.Children[0].Children[1]
+	if want := strings.Index(testDirectives, "unused"); int(a.StartOffset) != want || int(a.EndOffset) != want+6 {
+		t.Errorf("unexpected linter position: %+v", a)
+	}
+}
+
+func TestNotDirectives(t *testing.T) {
+	res, err := parse(&request{Content: "package p\n\n// go:generate x\n// Note: x\n/*go:embed x*/\n//nolintx\n//Go:x\n//go:\n//go-x:y\nvar x int"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := find(res, "Directive", "", nil); d != nil {
+		t.Errorf("unexpected directive: %+v", d)
+	}
+}
+
+func TestSplitDirectiveArgs(t *testing.T) {
+	tt := []struct {
+		in   string
+		want []string
+		ok   bool
+	}{
+		{"", nil, true},
+		{"a  b\tc", []string{"a", "b", "c"}, true},
+		{"\"a b\" `c\\d` \"\"", []string{"a b", `c\d`, ""}, true},
+		{`a"b" c`, []string{`a"b"`, "c"}, true},
+		{`"open`, nil, false},
+		{`"a"b`, nil, false},
+	}
+	for _, tc := range tt {
+		args, ok := splitDirectiveArgs(tc.in)
+		var got []string
+		for _, a := range args {
+			got = append(got, a.value)
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) || ok != tc.ok {
+			t.Errorf("splitDirectiveArgs(%q) = %q, %v; want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestPrintDirectives(t *testing.T) {
+	if got := printTree(t, testDirectives); got != testDirectives {
+		t.Errorf("different code:\n%s", got)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// How the contents of a request are encoded, chosen by its Encoding.
+const (
+	utf8Encoding   = "utf8"
+	base64Encoding = "base64"
+)
+
+// bom is the byte order mark some files start with.
+const bom = "\ufeff"
+
+// decode replaces the base64 contents of req with the bytes they hold, so
+// that files that aren't valid UTF-8, which JSON strings can't carry, are
+// parsed as they are.
+func (req *request) decode() *requestError {
+	if req.Encoding != base64Encoding {
+		return nil
+	}
+
+	var err *requestError
+	if req.Content, err = decodeBase64(req.Content); err != nil {
+		return err
+	}
+	for _, f := range req.Files {
+		if f.Content, err = decodeBase64(f.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeBase64(s string) (string, *requestError) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("invalid base64 content: %v", err),
+		}
+	}
+	return string(b), nil
+}
+
+// encode encodes the content and the diff of res as the contents of req
+// were, as both hold code.
+func (req *request) encode(res *response) {
+	if req.Encoding != base64Encoding {
+		return
+	}
+	if res.Content != "" {
+		res.Content = base64.StdEncoding.EncodeToString([]byte(res.Content))
+	}
+	if res.Diff != "" {
+		res.Diff = base64.StdEncoding.EncodeToString([]byte(res.Diff))
+	}
+}
+
+// addEncodingInfo sets the properties of a File node of content src that
+// tell how it's encoded: InvalidUTF8 if it's not all valid UTF-8, and BOM
+// if it starts with a byte order mark. They're only set when true.
+func addEncodingInfo(src string, s sink) {
+	if !utf8.ValidString(src) {
+		s.property("InvalidUTF8", true)
+	}
+	if strings.HasPrefix(src, bom) {
+		s.property("BOM", true)
+	}
+}
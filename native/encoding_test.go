@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestBase64Content(t *testing.T) {
+	// The string holds a byte that's not valid UTF-8, which a JSON string
+	// would turn into 3 bytes.
+	const content = "package p\n\nvar s = \"\xff\"\n\nvar x = 1\n"
+
+	req := &request{Encoding: "base64", Content: base64.StdEncoding.EncodeToString([]byte(content))}
+	if err := req.decode(); err != nil {
+		t.Fatalf("unexpected error: %v", err.Message)
+	}
+	res, err := parse(req)
+	if err == nil || !strings.Contains(err.Error(), "illegal UTF-8 encoding") {
+		t.Fatalf("expected an encoding error; got %v", err)
+	}
+	if res.Properties["InvalidUTF8"] != true {
+		t.Errorf("expected InvalidUTF8 to be set; got %v", res.Properties)
+	}
+	x := find(res, "Ident", "Name", "x")
+	if want := strings.Index(content, "x"); x == nil || int(x.StartOffset) != want {
+		t.Fatalf("expected x at offset %d; got %+v", want, x)
+	}
+}
+
+func TestBOM(t *testing.T) {
+	res, err := parse(&request{Content: "\ufeffpackage p"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Properties["BOM"] != true || res.Properties["InvalidUTF8"] != nil {
+		t.Errorf("expected only BOM to be set; got %v", res.Properties)
+	}
+	if p := find(res, "Ident", "Name", "p"); p.StartOffset != 11 {
+		t.Errorf("expected the name at offset 11; got %d", p.StartOffset)
+	}
+}
+
+func TestBase64Response(t *testing.T) {
+	enc := base64.StdEncoding.EncodeToString
+	res := handle(&request{Action: "format", Encoding: "base64", Content: enc([]byte("package p\nvar s = \"\xff\""))})
+	if res.Status != "fatal" {
+		t.Fatalf("expected status fatal; got %s", res.Status)
+	}
+
+	res = handle(&request{Action: "format", Encoding: "base64", Content: enc([]byte("package  p"))})
+	if res.Status != "ok" || res.Content != enc([]byte("package p\n")) {
+		t.Fatalf("expected the formatted content in base64; got %s %q %v", res.Status, res.Content, res.Errors)
+	}
+	diff, err := base64.StdEncoding.DecodeString(res.Diff)
+	if err != nil || !strings.Contains(string(diff), "+package p\n") {
+		t.Fatalf("expected the diff in base64; got %q: %v", res.Diff, err)
+	}
+}
+
+func TestInvalidEncoding(t *testing.T) {
+	res := handle(&request{Encoding: "latin1", Content: "package p"})
+	checkFailure(t, res, codeInvalidRequest)
+
+	res = handle(&request{Encoding: "base64", Content: "package p"})
+	checkFailure(t, res, codeInvalidRequest)
+
+	res = handle(&request{Encoding: "base64", Files: []*sourceFile{{Name: "a.go", Content: "!"}}})
+	checkFailure(t, res, codeInvalidRequest)
+}
@@ -0,0 +1,50 @@
+package main
+
+import "go/scanner"
+
+// parseError is a single syntax error found in the source. It's encoded with
+// the same keys as a node, so the driver turns it into a ParseError node
+// next to the root of the file, positioned where the error starts.
+type parseError struct {
+	InternalType string
+	Filename     string `json:",omitempty"`
+	Message      string
+	StartOffset  int
+	StartLine    int
+	StartColumn  int
+}
+
+// parseErrors unpacks err into one parseError per error it holds.
+func parseErrors(err error) []*parseError {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return []*parseError{{InternalType: "ParseError", Message: err.Error()}}
+	}
+
+	var errs []*parseError
+	for _, e := range list {
+		errs = append(errs, &parseError{
+			InternalType: "ParseError",
+			Filename:     e.Pos.Filename,
+			Message:      e.Msg,
+			StartOffset:  e.Pos.Offset,
+			StartLine:    e.Pos.Line,
+			StartColumn:  e.Pos.Column,
+		})
+	}
+	return errs
+}
+
+// errorMessages returns the messages of err, one per error it holds.
+func errorMessages(err error) []string {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var msgs []string
+	for _, e := range list {
+		msgs = append(msgs, e.Error())
+	}
+	return msgs
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseErrors(t *testing.T) {
+	res := handle(&request{Content: "package p\n\nvar a = )\nvar b = ]\n"})
+
+	msgs := []string{
+		"3:9: expected operand, found ')'",
+		"4:1: expected ';', found 'var'",
+		"4:9: expected operand, found ']'",
+		"4:11: expected ';', found 'EOF'",
+	}
+	if !cmp.Equal(msgs, res.Errors) {
+		t.Fatalf("different error messages: %s", cmp.Diff(msgs, res.Errors))
+	}
+
+	errs := []*parseError{
+		{InternalType: "ParseError", Message: "expected operand, found ')'", StartOffset: 19, StartLine: 3, StartColumn: 9},
+		{InternalType: "ParseError", Message: "expected ';', found 'var'", StartOffset: 21, StartLine: 4, StartColumn: 1},
+		{InternalType: "ParseError", Message: "expected operand, found ']'", StartOffset: 29, StartLine: 4, StartColumn: 9},
+		{InternalType: "ParseError", Message: "expected ';', found 'EOF'", StartOffset: 31, StartLine: 4, StartColumn: 11},
+	}
+	if !cmp.Equal(errs, res.AST.Errors) {
+		t.Fatalf("different errors: %s", cmp.Diff(errs, res.AST.Errors))
+	}
+}
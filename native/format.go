@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+)
+
+// formatResponse formats the content of req as gofmt does, simplifying it
+// as gofmt -s when asked to. The response holds the formatted code, whether
+// it was already formatted and, when it wasn't, the diff between both.
+func formatResponse(req *request) *response {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, req.Filename, req.Content, parser.AllErrors|parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		// gofmt doesn't format code with syntax errors either.
+		return &response{
+			ID:     req.ID,
+			Status: "fatal",
+			Errors: errorMessages(err),
+			AST:    &result{Errors: parseErrors(err)},
+		}
+	}
+
+	if req.Simplify {
+		simplify(f)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fs, f); err != nil {
+		return failure(req.ID, &requestError{
+			Code:    codeInvalidRequest,
+			Message: "could not format the content: " + err.Error(),
+		})
+	}
+
+	res := &response{
+		ID:        req.ID,
+		Status:    "ok",
+		AST:       &result{},
+		Content:   buf.String(),
+		Formatted: buf.String() == req.Content,
+	}
+	if !res.Formatted {
+		name := req.Filename
+		if name == "" {
+			name = "<standard input>"
+		}
+		res.Diff = unifiedDiff(name+".orig", req.Content, name, res.Content)
+	}
+	return res
+}
+
+// simplify applies the simplifications of gofmt -s to f:
+//
+//   - empty declaration groups such as "var ()" are removed,
+//   - the types of composite literals are elided where they're implied by
+//     the type of the literal holding them, &T{} included,
+//   - s[a:len(s)] becomes s[a:],
+//   - blank variables are dropped from range clauses.
+func simplify(f *ast.File) {
+	decls := f.Decls[:0]
+	for _, d := range f.Decls {
+		if g, ok := d.(*ast.GenDecl); !ok || !emptyGroup(f, g) {
+			decls = append(decls, d)
+		}
+	}
+	f.Decls = decls
+
+	ast.Walk(simplifier{}, f)
+}
+
+// emptyGroup tells whether g declares nothing and holds no comments.
+func emptyGroup(f *ast.File, g *ast.GenDecl) bool {
+	if g.Doc != nil || len(g.Specs) > 0 {
+		return false
+	}
+	for _, cg := range f.Comments {
+		if g.Pos() <= cg.Pos() && cg.End() <= g.End() {
+			return false
+		}
+	}
+	return true
+}
+
+// simplifier is the visitor simplifying the expressions and statements of a
+// file.
+type simplifier struct{}
+
+func (s simplifier) Visit(n ast.Node) ast.Visitor {
+	switch n := n.(type) {
+	case *ast.CompositeLit:
+		var key, elem ast.Expr
+		switch t := n.Type.(type) {
+		case *ast.ArrayType:
+			elem = t.Elt
+		case *ast.MapType:
+			key, elem = t.Key, t.Value
+		}
+		if elem == nil {
+			break
+		}
+
+		for i := range n.Elts {
+			x := &n.Elts[i]
+			if kv, ok := (*x).(*ast.KeyValueExpr); ok {
+				if key != nil {
+					s.simplifyElement(key, &kv.Key)
+				}
+				x = &kv.Value
+			}
+			s.simplifyElement(elem, x)
+		}
+		// The elements are already walked, and the type has nothing to
+		// simplify as gofmt sees it.
+		return nil
+	case *ast.SliceExpr:
+		// It may be wrong if len is redeclared, but gofmt takes the risk.
+		if n.Max != nil {
+			break
+		}
+		x, ok := n.X.(*ast.Ident)
+		call, _ := n.High.(*ast.CallExpr)
+		if !ok || call == nil || len(call.Args) != 1 || call.Ellipsis.IsValid() {
+			break
+		}
+		if isIdent(call.Fun, "len") && isIdent(call.Args[0], x.Name) {
+			n.High = nil
+		}
+	case *ast.RangeStmt:
+		if isIdent(n.Value, "_") {
+			n.Value = nil
+		}
+		if n.Value == nil && isIdent(n.Key, "_") {
+			n.Key = nil
+		}
+	}
+	return s
+}
+
+// simplifyElement simplifies the element *x of a composite literal whose
+// elements, or keys, are of type typ.
+func (s simplifier) simplifyElement(typ ast.Expr, x *ast.Expr) {
+	ast.Walk(s, *x)
+
+	if lit, ok := (*x).(*ast.CompositeLit); ok && sameNode(typ, lit.Type) {
+		lit.Type = nil
+	}
+	ptr, ok := typ.(*ast.StarExpr)
+	if !ok {
+		return
+	}
+	if addr, ok := (*x).(*ast.UnaryExpr); ok && addr.Op == token.AND {
+		if lit, ok := addr.X.(*ast.CompositeLit); ok && sameNode(ptr.X, lit.Type) {
+			lit.Type = nil
+			*x = lit
+		}
+	}
+}
+
+// isIdent tells whether x is the identifier name.
+func isIdent(x ast.Expr, name string) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+var (
+	posType    = reflect.TypeOf(token.NoPos)
+	objectType = reflect.TypeOf((*ast.Object)(nil))
+)
+
+// sameNode tells whether a and b are the same code, wherever they are.
+func sameNode(a, b ast.Node) bool {
+	return sameValue(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func sameValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Type() {
+	case posType, objectType:
+		return true
+	}
+	switch a.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return sameValue(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !sameValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !sameValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Interface() == b.Interface()
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormat(t *testing.T) {
+	const content = "package p\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n\nfunc f()  {\nfmt.Println(os.Args)\n}"
+
+	res := handle(&request{Action: "format", Filename: "p.go", Content: content})
+	if res.Status != "ok" {
+		t.Fatalf("could not format: %v", res.Errors)
+	}
+	const want = "package p\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc f() {\n\tfmt.Println(os.Args)\n}\n"
+	if res.Content != want {
+		t.Errorf("different code: %s", cmp.Diff(want, res.Content))
+	}
+	if res.Formatted {
+		t.Errorf("expected the content not to be formatted")
+	}
+	const diff = `diff p.go.orig p.go
+--- p.go.orig
++++ p.go
+@@ -1,10 +1,10 @@
+ package p
+ 
+ import (
+-	"os"
+ 	"fmt"
++	"os"
+ )
+ 
+-func f()  {
+-fmt.Println(os.Args)
+-}
+\ No newline at end of file
++func f() {
++	fmt.Println(os.Args)
++}
+`
+	if res.Diff != diff {
+		t.Errorf("different diff: %s", cmp.Diff(diff, res.Diff))
+	}
+
+	res = handle(&request{Action: "format", Content: want})
+	if res.Status != "ok" || !res.Formatted || res.Content != want || res.Diff != "" {
+		t.Errorf("expected the content to be formatted; got %+v", res)
+	}
+}
+
+func TestFormatSimplify(t *testing.T) {
+	const content = `package p
+
+var ()
+
+type T struct{ a, b int }
+
+var (
+	s = []T{T{1, 2}, T{a: 3}}
+	p = []*T{&T{1, 2}}
+	m = map[T][]int{T{1, 2}: []int{3}}
+	f = []func(){func() {}}
+)
+
+func g(s []int) {
+	for i, _ := range s[1:len(s)] {
+		_ = i
+	}
+	for _ = range s {
+	}
+}
+`
+	const want = `package p
+
+type T struct{ a, b int }
+
+var (
+	s = []T{{1, 2}, {a: 3}}
+	p = []*T{{1, 2}}
+	m = map[T][]int{{1, 2}: {3}}
+	f = []func(){func() {}}
+)
+
+func g(s []int) {
+	for i := range s[1:] {
+		_ = i
+	}
+	for range s {
+	}
+}
+`
+	res := handle(&request{Action: "format", Content: content, Simplify: true})
+	if res.Content != want {
+		t.Errorf("different code: %s", cmp.Diff(want, res.Content))
+	}
+
+	res = handle(&request{Action: "format", Content: content})
+	if !res.Formatted {
+		t.Errorf("expected no simplification; got %s", res.Diff)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	res := handle(&request{Action: "format", Content: "package p\n\nvar a = )"})
+	if res.Status != "fatal" || res.Content != "" || len(res.AST.Errors) == 0 {
+		t.Fatalf("expected a syntax error; got %+v", res)
+	}
+
+	res = handle(&request{Action: "format", Files: []*sourceFile{{Name: "a.go", Content: "package p"}}})
+	checkFailure(t, res, codeInvalidRequest)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\nm\n"
+	const want = `diff old new
+--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,5 +9,5 @@
+ i
+ j
+ k
+-l
++L
+ m
+`
+	if got := unifiedDiff("old", old, "new", new); got != want {
+		t.Errorf("different diff: %s", cmp.Diff(want, got))
+	}
+
+	// Changes closer than twice the context share a hunk.
+	new = "a\nB\nc\nd\ne\nf\ng\nH\ni\nj\nk\nl\nm\n"
+	if got := unifiedDiff("old", old, "new", new); !strings.Contains(got, "@@ -1,11 +1,11 @@\n") {
+		t.Errorf("expected a single hunk; got %s", got)
+	}
+
+	if got := unifiedDiff("old", "", "new", "a\n"); got != "diff old new\n--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n" {
+		t.Errorf("wrong diff from an empty text: %q", got)
+	}
+	if got := unifiedDiff("old", old, "new", old); got != "" {
+		t.Errorf("expected no diff; got %q", got)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// What the content of a request is, chosen by its Fragment.
+const (
+	fileFragment  = "file"
+	exprFragment  = "expr"
+	stmtsFragment = "stmts"
+	declsFragment = "decls"
+)
+
+// fragmentWrappers holds the code statements and declarations are wrapped
+// in to be parsed as a file. The prefixes take no line, so that only the
+// columns of the first line of the content need to be corrected.
+var fragmentWrappers = map[string][2]string{
+	stmtsFragment: {"package p; func _() {", "\n}"},
+	declsFragment: {"package p;", ""},
+}
+
+// prepareFragment parses the content of req as a fragment of a file. The
+// tree is rooted at the expression, or at a ListOfStmt or ListOfDecl node
+// spanning the content, without the code it was wrapped in to be parsed.
+// Its positions are those in the content.
+func prepareFragment(req *request) (document, error) {
+	fs := token.NewFileSet()
+	mode := req.parserMode()
+
+	var (
+		f     *ast.File
+		nodes []ast.Node
+		err   error
+		start int
+	)
+	if req.Fragment == exprFragment {
+		var x ast.Expr
+		x, err = parser.ParseExprFrom(fs, req.Filename, req.Content, mode)
+		if x == nil {
+			return nil, err
+		}
+		// The expression is declared in a file of its own, so that it's
+		// handled as the other fragments from here on.
+		f = &ast.File{
+			Package: fileBase(fs),
+			Name:    ast.NewIdent("p"),
+			Decls: []ast.Decl{&ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{
+				&ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent("_")}, Values: []ast.Expr{x}},
+			}}},
+		}
+		nodes = []ast.Node{x}
+	} else {
+		w := fragmentWrappers[req.Fragment]
+		start = len(w[0])
+		f, err = parser.ParseFile(fs, req.Filename, w[0]+req.Content+w[1], mode)
+		if f != nil && req.Fragment == stmtsFragment {
+			err = strayBrace(fs, f, start+len(req.Content), err)
+		}
+		err = shiftErrors(err, start, req.Content)
+		if f == nil {
+			return nil, err
+		}
+		var ok bool
+		if nodes, ok = fragmentNodes(f, req.Fragment); !ok {
+			// Not even the wrapper could be parsed.
+			return nil, err
+		}
+	}
+
+	// The code the fragment is wrapped in is left out, so that it's
+	// numbered as the same code in a file.
+	ids := rootIDs([]*ast.File{f}, nodes)
+	var (
+		pkg  *types.Package
+		info *types.Info
+	)
+	if req.TypeCheck {
+		pkg, info = typeCheck(fs, []*ast.File{f})
+	}
+	return func(s sink) {
+		c := newConverter(s, fs, f, ids)
+		c.filename = req.Filename
+		c.start = start
+		c.limit = c.base + token.Pos(start+len(req.Content))
+		c.columns = columnTable(req.Content, req.ColumnMode)
+		c.pkg, c.info = pkg, info
+		if req.Fragment == exprFragment {
+			c.walk(nodes[0], "")
+			return
+		}
+		c.fragment(req.Fragment, nodes, freeComments(f))
+	}, err
+}
+
+// fileBase returns the position of the first byte of the only file of fs.
+func fileBase(fs *token.FileSet) token.Pos {
+	var base token.Pos
+	fs.Iterate(func(tf *token.File) bool {
+		base = token.Pos(tf.Base())
+		return false
+	})
+	return base
+}
+
+// fragmentNodes returns the statements or declarations of the fragment
+// parsed in f, without its wrapper, or false if it's not in f.
+func fragmentNodes(f *ast.File, fragment string) ([]ast.Node, bool) {
+	var nodes []ast.Node
+	switch fragment {
+	case stmtsFragment:
+		if len(f.Decls) == 0 {
+			return nil, false
+		}
+		fn, ok := f.Decls[0].(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			return nil, false
+		}
+		for _, s := range fn.Body.List {
+			nodes = append(nodes, s)
+		}
+	case declsFragment:
+		for _, d := range f.Decls {
+			nodes = append(nodes, d)
+		}
+	}
+	return nodes, true
+}
+
+// strayBrace returns err along with an error at the brace closing the
+// function statements are wrapped in too early, before the end of their
+// content, if there's one: what follows it is parsed as declarations, and
+// left out of the statements. The errors past the content, about the rest
+// of the wrapper, are dropped.
+func strayBrace(fs *token.FileSet, f *ast.File, end int, err error) error {
+	if len(f.Decls) == 0 {
+		return err
+	}
+	fn, ok := f.Decls[0].(*ast.FuncDecl)
+	if !ok || fn.Body == nil || !fn.Body.Rbrace.IsValid() {
+		return err
+	}
+	// The brace of the wrapper is the one after the content.
+	pos := fs.Position(fn.Body.Rbrace)
+	if len(f.Decls) == 1 && pos.Offset >= end {
+		return err
+	}
+
+	list, ok := err.(scanner.ErrorList)
+	if err != nil && !ok {
+		return err
+	}
+	var errs scanner.ErrorList
+	for _, e := range list {
+		if e.Pos.Offset < end {
+			errs = append(errs, e)
+		}
+	}
+	errs.Add(pos, "expected statement, found '}'")
+	errs.Sort()
+	return errs
+}
+
+// fragment converts the statements or declarations of a fragment into the
+// list they form, which spans all the content. The comments attached to none
+// of them are kept in its Comments, as in a File.
+func (c *converter) fragment(fragment string, nodes []ast.Node, comments []*ast.CommentGroup) {
+	typ := "ListOfStmt"
+	if fragment == declsFragment {
+		typ = "ListOfDecl"
+	}
+	c.out.openNode(typ, "", c.position(c.base+token.Pos(c.start)), c.position(c.limit))
+
+	base := len(c.stack)
+	c.pushClose()
+	if len(comments) > 0 {
+		c.pushClose()
+		for i := len(comments) - 1; i >= 0; i-- {
+			c.push(comments[i], "")
+		}
+		c.pushList("ListOfCommentGroup", "Comments")
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		c.push(nodes[i], "")
+	}
+	c.run(base)
+}
+
+// shiftErrors moves the positions of the errors in err from the wrapper
+// content was parsed in into content, which starts at offset start of it.
+// The errors found in the end of the wrapper are moved to the end of
+// content.
+func shiftErrors(err error, start int, content string) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return err
+	}
+
+	for _, e := range list {
+		if e.Pos.Line == 1 {
+			e.Pos.Column -= start
+		}
+		e.Pos.Offset -= start
+		if e.Pos.Offset > len(content) {
+			e.Pos.Offset = len(content)
+			e.Pos.Line = 1 + strings.Count(content, "\n")
+			e.Pos.Column = len(content) - strings.LastIndex(content, "\n")
+		}
+		if e.Pos.Offset < 0 || e.Pos.Column < 1 {
+			e.Pos.Offset, e.Pos.Line, e.Pos.Column = 0, 1, 1
+		}
+	}
+	return list
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFragment(t *testing.T) {
+	tt := []struct {
+		fragment, content, root string
+		// ident is where x is found in content.
+		line, column int
+	}{
+		{"expr", "a + f(x...)", "BinaryExpr", 1, 7},
+		{"stmts", "if a {\n\tx++\n}\nreturn", "ListOfStmt", 2, 2},
+		{"stmts", "y := 1 // c\nz := x", "ListOfStmt", 2, 6},
+		{"decls", "import \"fmt\"\n\nfunc x() { fmt.Println() }", "ListOfDecl", 3, 6},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.fragment, func(t *testing.T) {
+			res, err := parse(&request{Fragment: tc.fragment, Content: tc.content})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.InternalType != tc.root {
+				t.Fatalf("expected a %s; got a %s", tc.root, res.InternalType)
+			}
+			if res.StartOffset != 0 || int(res.EndOffset) != len(tc.content) {
+				t.Errorf("expected the root to span the content; got %d-%d", res.StartOffset, res.EndOffset)
+			}
+
+			x := find(res, "Ident", "Name", "x")
+			if x == nil {
+				t.Fatalf("x not found")
+			}
+			if want := strings.Index(tc.content, "x"); int(x.StartOffset) != want {
+				t.Errorf("expected x at offset %d; got %d", want, x.StartOffset)
+			}
+			if x.StartLine != tc.line || x.StartColumn != tc.column {
+				t.Errorf("expected x at %d:%d; got %d:%d", tc.line, tc.column, x.StartLine, x.StartColumn)
+			}
+		})
+	}
+}
+
+func TestFragmentComments(t *testing.T) {
+	res, err := parse(&request{Fragment: "stmts", Content: "x := 1 // one"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := find(res, "Comment", "Text", "// one")
+	if c == nil || c.StartOffset != 7 || c.StartColumn != 8 {
+		t.Fatalf("expected the comment at offset 7; got %+v", c)
+	}
+}
+
+func TestFragmentErrors(t *testing.T) {
+	tt := []struct {
+		fragment, content string
+		offset, column    int
+	}{
+		{"expr", "a +", 3, 4},
+		{"stmts", "x := )", 5, 6},
+		{"stmts", "if a {", 6, 7},
+		{"stmts", "x := 1\n}\nfunc g() {\n}", 7, 1},
+		{"decls", "var x int\nvar = 1", 14, 5},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.content, func(t *testing.T) {
+			res := handle(&request{Fragment: tc.fragment, Content: tc.content})
+			if res.Status != "error" {
+				t.Fatalf("expected status error; got %s: %v", res.Status, res.Errors)
+			}
+			e := res.AST.Errors[0]
+			if e.StartOffset != tc.offset || e.StartColumn != tc.column {
+				t.Errorf("expected an error at offset %d, column %d; got %+v", tc.offset, tc.column, e)
+			}
+			for _, e := range res.AST.Errors {
+				if e.StartOffset > len(tc.content) {
+					t.Errorf("error past the content: %+v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidFragment(t *testing.T) {
+	res := handle(&request{Fragment: "line", Content: "x"})
+	checkFailure(t, res, codeInvalidRequest)
+
+	res = handle(&request{Fragment: "expr", Files: []*sourceFile{{Name: "a.go", Content: "package p"}}})
+	checkFailure(t, res, codeInvalidRequest)
+}
+
+func TestFragmentIDs(t *testing.T) {
+	res, err := parse(&request{Fragment: "stmts", Content: "x := 1\nprintln(x)"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The function the statements are wrapped in takes no ID.
+	if a := find(res, "AssignStmt", "", nil); a.Properties["NodeID"] != 1 {
+		t.Errorf("expected the first ID; got %v", a.Properties["NodeID"])
+	}
+	if x := find(find(res, "CallExpr", "", nil), "Ident", "Name", "x"); x.Properties["DeclRef"] != 1 {
+		t.Errorf("expected the use of x to refer to the first ID; got %+v", x)
+	}
+}
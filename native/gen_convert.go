@@ -0,0 +1,518 @@
+//go:build ignore
+
+// gen_convert generates convert_gen.go: a converter method per go/ast node
+// type, writing its fields into a sink without any reflection, and the
+// children function used to walk the trees without recursion. It also
+// generates print_gen.go, which builds the go/ast nodes back from a tree.
+//
+// The node types and their fields are read from the go/ast package itself, so
+// running go generate with a newer Go version picks up any new syntax.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"sort"
+)
+
+// ignoredFields are not part of the tree: the scope and objects are exposed
+// through NodeID and DeclRef, and Imports repeats the import specs.
+var ignoredFields = map[string]bool{
+	"Imports": true,
+	"Scope":   true,
+	"Obj":     true,
+}
+
+// offsetFields are the positions written into the tree, as they tell apart
+// different syntax: f(x...) from f(x), grouped declarations from single ones
+// and aliases from type definitions.
+var offsetFields = map[string]bool{
+	"CallExpr.Ellipsis": true,
+	"GenDecl.Lparen":    true,
+	"TypeSpec.Assign":   true,
+}
+
+// extraChildren are written by hand after the properties of the node types
+// they belong to, before their other children, as they don't come from
+// go/ast fields.
+var extraChildren = map[string]string{
+	"BasicLit":   "c.structTag(n, name)",
+	"Comment":    "c.directive(n)",
+	"ImportSpec": "c.cgoPreamble(n)",
+}
+
+// ignoredTypes are nodes that never appear in the tree of a file, or are
+// written by hand, as the Directive of a Comment is.
+var ignoredTypes = map[string]bool{
+	"Directive": true,
+	"Package":   true,
+}
+
+func main() {
+	pkg, err := importer.ForCompiler(token.NewFileSet(), "source", nil).Import("go/ast")
+	if err != nil {
+		log.Fatalf("could not import go/ast: %v", err)
+	}
+	g := &generator{
+		pkg:  pkg,
+		node: pkg.Scope().Lookup("Node").Type().Underlying().(*types.Interface),
+	}
+
+	var names []string
+	for _, name := range pkg.Scope().Names() {
+		if g.isNodeStruct(pkg.Scope().Lookup(name).Type()) && !ignoredTypes[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	g.printf("// Code generated by gen_convert.go from go/ast; DO NOT EDIT.\n\n")
+	g.printf("package main\n\n")
+	g.printf("import \"go/ast\"\n\n")
+
+	g.printf("// convert writes n, as the field name of its parent, into c.out.\n")
+	g.printf("func (c *converter) convert(n ast.Node, name string) {\n")
+	g.printf("switch n := n.(type) {\n")
+	for _, name := range names {
+		g.printf("case *ast.%s:\n c.convert%s(n, name)\n", name, name)
+	}
+	g.printf("}\n}\n\n")
+
+	for _, name := range names {
+		if err := g.convertFunc(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	g.printf("// children calls f with each of the children of n, in order.\n")
+	g.printf("func children(n ast.Node, f func(ast.Node)) {\n")
+	g.printf("switch n := n.(type) {\n")
+	for _, name := range names {
+		g.childrenCase(name)
+	}
+	g.printf("}\n}\n")
+	g.write("convert_gen.go")
+
+	g.printf("// Code generated by gen_convert.go from go/ast; DO NOT EDIT.\n\n")
+	g.printf("package main\n\n")
+	g.printf("import \"go/ast\"\n\n")
+	g.printf("// build returns the go/ast node of n.\n")
+	g.printf("func (b *builder) build(n *treeNode) ast.Node {\n")
+	g.printf("switch n.InternalType {\n")
+	for _, name := range names {
+		g.printf("case %q:\nreturn b.build%s(n)\n", name, name)
+	}
+	g.printf("}\nb.fail(\"unknown node type %%q\", n.InternalType)\nreturn nil\n}\n\n")
+	for _, name := range names {
+		if err := g.buildFunc(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+	g.write("print_gen.go")
+
+	doc, err := g.propertiesDoc(names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("PROPERTIES.md", doc, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// propertiesHeader documents the properties that don't come from go/ast
+// fields.
+const propertiesHeader = `<!-- Code generated by gen_convert.go from go/ast; DO NOT EDIT. -->
+
+# Node properties
+
+Every node of the native tree has the properties listed below for its type.
+Values keep their JSON type: a string, a boolean or a number. Tokens, such
+as operators and keywords, are strings as go/token writes them. String
+properties are left out when empty. The Dir of a ChanType is 1 for send-only
+channels, 2 for receive-only ones and 3 for the rest. Positions, such as the
+Lparen of a grouped GenDecl, are offsets in the file, only set when valid.
+
+Some properties don't come from go/ast, and can be found on any node:
+
+| Property | Type | Set on |
+| --- | --- | --- |
+| NodeID | number | Nodes declaring an object some identifier resolves to, unless SkipObjectResolution is set, and nodes a directive attaches to. |
+| DeclRef | number | Identifiers resolved to a declaration, with its NodeID. |
+| ResolvedType | string | Expressions and identifiers, when type checking. |
+| ObjectKind | string | Identifiers of an object, when type checking. |
+| ConstantValue | string | Constant expressions, when type checking. |
+| StringValue | string | STRING literals, unquoted. |
+| IntValue | string | INT literals, exactly in decimal. |
+| FloatValue | string | FLOAT literals, as the nearest float64. |
+| RuneValue | number | CHAR literals, as a code point. |
+| ImagValue | string | IMAG literals, as the nearest float64 of their imaginary part. |
+| ExactValue | string | FLOAT and IMAG literals, as the exact value of the float or imaginary part: an integer, a fraction such as 1/10, or a hexadecimal mantissa with a binary exponent for huge exponents. |
+| DecodeError | boolean | Malformed literals, instead of their value. |
+| Filename, GOOS, GOARCH, BuildConstraint | string | Files. |
+| IsTest | boolean | Files. |
+| InvalidUTF8, BOM | boolean | Files that aren't valid UTF-8 or start with a byte order mark, only when true. |
+| Name | string | Packages. |
+| ConsistentName | boolean | Packages. |
+| Path | string | Imports of a package. |
+
+## Directives
+
+Comments holding a directive, such as //go:embed, //go:linkname or //nolint,
+have a Directive node named Directive as their only child. It has the Tool
+and Name of //tool:name directives, or only the Name of //line, //export,
+//extern and //nolint, and its Args as they're written. Unless they're
+malformed, the arguments are also split as go/ast does into the DirectiveArg
+nodes of its ArgList, with their unquoted text as Value. The Args of
+//nolint:errcheck,unused are its linters, errcheck,unused, split at commas,
+without the explanation that may follow them. A directive
+documenting a declaration, or written next to a spec or field, attaches to
+it: the spec for declarations of a single spec, so that //go:embed finds
+its variable.
+
+| Property | Type | Set on |
+| --- | --- | --- |
+| Tool, Name, Args | string | Directives. |
+| Target | string | Directives, with the names the node they attach to declares. |
+| TargetRef | number | Directives, with the NodeID of the node they attach to. |
+| Value | string | DirectiveArgs. |
+
+## Struct tags
+
+The Tag of a field is also split into keys, as reflect.StructTag reads it,
+in a list named StructTag of its BasicLit. Each key:"value" pair is a
+StructTagKey node, with the key and the quoted value as StructTagText nodes
+named Key and Value. The value is also split at commas, as encoding/json
+does, into a Name and StructTagOption nodes, named Options. Offsets within
+the tag are only exact when it holds no escapes; otherwise the nodes span the
+whole tag.
+
+| Property | Type | Set on |
+| --- | --- | --- |
+| MalformedTag | boolean | Tags reflect.StructTag can't read to the end, only when true. The keys before the error are kept. |
+| Name | string | StructTagKeys, with the value before the first comma, if not empty. |
+| Value | string | StructTagTexts, the key or the unquoted value, and StructTagOptions. |
+
+## Cgo
+
+The import "C" specs of a file hold their preamble, the comment cgo reads C
+code from, in a CgoPreamble node named Preamble, before their other
+children. Its Text is the C code, without the comment markers, and its
+Directives hold a CgoDirective node per #cgo line. Their arguments are also
+split as the go command does into the CgoArg nodes of their ArgList, with
+their unquoted text as Value, unless a quote is left open.
+
+| Property | Type | Set on |
+| --- | --- | --- |
+| Text | string | CgoPreambles. |
+| Verb | string | CgoDirectives, such as CFLAGS, LDFLAGS, pkg-config or noescape. |
+| Constraint, Args | string | CgoDirectives, as they're written. |
+| Value | string | CgoArgs. |
+| CgoRef | string | SelectorExprs referring to C.xxx in files importing "C", with the C name. |
+
+## Module files
+
+The go.mod, go.work and go.sum files of a module, parsed when the Language of
+a request says so, have nodes of their own. The paths and versions of their
+directives are Path and Version nodes, named after what they are to the
+directive, with their unquoted text as Value.
+
+| Node type | Properties | Children |
+| --- | --- | --- |
+| ModFile, WorkFile | | Directives and Blocks, then a ListOfComment named Comments. |
+| SumFile | | A Checksum per line. |
+| Block | Verb: string | The directives in parentheses. |
+| Module | Deprecated: string | Path. |
+| Go, Toolchain | | Version. |
+| Godebug | Key, Value: string | |
+| Require | Indirect: boolean | Path, Version. |
+| Exclude | | Path, Version. |
+| Replace | | Old, OldVersion if any, New, NewVersion if any. |
+| Retract | Rationale: string | Version, or Low and High. |
+| Use, Tool, Ignore | | Path. |
+| BadDirective | | |
+| Checksum | Hash: string, GoMod: boolean | Path, Version. |
+| Comment | Text: string | |
+
+## Properties by node type
+
+`
+
+// propertiesDoc returns the documentation of the properties of the node
+// types names.
+func (g *generator) propertiesDoc(names []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(propertiesHeader)
+	for _, name := range names {
+		var rows []string
+		for _, f := range g.fields(name) {
+			if g.isNode(f.Type()) {
+				continue
+			}
+			if _, ok := f.Type().(*types.Slice); ok {
+				continue
+			}
+			_, kind, err := g.property(f.Type(), "")
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", name, f.Name(), err)
+			}
+			if kind == "bool" {
+				kind = "boolean"
+			}
+			rows = append(rows, fmt.Sprintf("| %s | %s | %s |\n", f.Name(), kind, g.typeString(f.Type())))
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "### %s\n\n| Property | Type | Go type |\n| --- | --- | --- |\n", name)
+		for _, r := range rows {
+			buf.WriteString(r)
+		}
+		buf.WriteString("\n")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+type generator struct {
+	buf  bytes.Buffer
+	pkg  *types.Package
+	node *types.Interface
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// write formats the generated code into the file name, and starts over.
+func (g *generator) write(name string) {
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		log.Fatalf("could not format the generated code of %s: %v", name, err)
+	}
+	if err := os.WriteFile(name, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+	g.buf.Reset()
+}
+
+// isPos reports whether t is token.Pos.
+func isPos(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg().Path() == "go/token" && named.Obj().Name() == "Pos"
+}
+
+// typeString returns t as it's written in the generated code.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, (*types.Package).Name)
+}
+
+// isNodeStruct reports whether t is a struct type whose pointer is an
+// ast.Node.
+func (g *generator) isNodeStruct(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() != g.pkg {
+		return false
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return false
+	}
+	return types.Implements(types.NewPointer(named), g.node)
+}
+
+// isNodeInterface reports whether t is one of the node interfaces, such as
+// ast.Expr or ast.Stmt.
+func (g *generator) isNodeInterface(t types.Type) bool {
+	if _, ok := t.Underlying().(*types.Interface); !ok {
+		return false
+	}
+	return types.Implements(t, g.node)
+}
+
+// isNode reports whether t is a pointer to a node or a node interface.
+func (g *generator) isNode(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok && g.isNodeStruct(p.Elem()) {
+		return true
+	}
+	return g.isNodeInterface(t)
+}
+
+// fields returns the fields of the node type name that are written into the
+// tree.
+func (g *generator) fields(name string) []*types.Var {
+	st := g.pkg.Scope().Lookup(name).Type().Underlying().(*types.Struct)
+
+	var fields []*types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if ignoredFields[f.Name()] || !f.Exported() {
+			continue
+		}
+		if isPos(f.Type()) && !offsetFields[name+"."+f.Name()] {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// property returns the expression of the property value of the scalar x,
+// and its JSON type: values with a String method, such as tokens, are
+// written as strings, while booleans and integers keep their type.
+func (g *generator) property(t types.Type, x string) (value, kind string, err error) {
+	if named, ok := t.(*types.Named); ok {
+		if obj, _, _ := types.LookupFieldOrMethod(named, false, named.Obj().Pkg(), "String"); obj != nil {
+			return x + ".String()", "string", nil
+		}
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported type %s", t)
+	}
+	switch {
+	case basic.Kind() == types.String:
+		return x, "string", nil
+	case basic.Kind() == types.Bool:
+		return x, "bool", nil
+	case basic.Info()&types.IsInteger != 0:
+		return fmt.Sprintf("int(%s)", x), "number", nil
+	}
+	return "", "", fmt.Errorf("unsupported type %s", t)
+}
+
+// convertFunc writes the method converting the node type name. Its
+// properties are written right away, while its children are pushed into the
+// stack of the converter, in reverse order, after the closing of the node.
+func (g *generator) convertFunc(name string) error {
+	g.printf("func (c *converter) convert%s(n *ast.%s, name string) {\n", name, name)
+	g.printf("if n == nil {\nreturn\n}\n")
+	g.printf("c.open(n, %q, name)\n", name)
+
+	var children []string
+	for _, f := range g.fields(name) {
+		x := "n." + f.Name()
+		if g.isNode(f.Type()) {
+			children = append(children, fmt.Sprintf("c.push(%s, %q)", x, f.Name()))
+			continue
+		}
+
+		if s, ok := f.Type().(*types.Slice); ok {
+			elem := s.Elem()
+			if !g.isNode(elem) {
+				return fmt.Errorf("%s.%s: slice of non nodes %s", name, f.Name(), elem)
+			}
+			if p, ok := elem.(*types.Pointer); ok {
+				elem = p.Elem()
+			}
+			list := "ListOf" + elem.(*types.Named).Obj().Name()
+			children = append(children, fmt.Sprintf(
+				"if len(%s) > 0 {\nc.pushClose()\nfor i := len(%s) - 1; i >= 0; i-- {\nc.push(%s[i], \"\")\n}\nc.pushList(%q, %q)\n}",
+				x, x, x, list, f.Name()))
+			continue
+		}
+
+		if isPos(f.Type()) {
+			g.printf("if %s.IsValid() {\nc.out.property(%q, int(c.position(%s).offset))\n}\n", x, f.Name(), x)
+			continue
+		}
+
+		value, _, err := g.property(f.Type(), x)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %v", name, f.Name(), err)
+		}
+		if b, ok := f.Type().Underlying().(*types.Basic); ok && b.Kind() == types.String {
+			// Fields such as File.GoVersion are empty unless set.
+			g.printf("if %s != \"\" {\nc.out.property(%q, %s)\n}\n", x, f.Name(), value)
+		} else {
+			g.printf("c.out.property(%q, %s)\n", f.Name(), value)
+		}
+	}
+
+	if x, ok := extraChildren[name]; ok {
+		g.printf("%s\n", x)
+	}
+	g.printf("c.pushClose()\n")
+	for i := len(children) - 1; i >= 0; i-- {
+		g.printf("%s\n", children[i])
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// buildFunc writes the method building the node type name from a tree node.
+// Its positions are set by hand, once all its fields are.
+func (g *generator) buildFunc(name string) error {
+	g.printf("func (b *builder) build%s(n *treeNode) *ast.%s {\n", name, name)
+	g.printf("x := &ast.%s{}\n", name)
+
+	var children, props []string
+	for _, f := range g.fields(name) {
+		x := "x." + f.Name()
+		switch t := f.Type().(type) {
+		case *types.Slice:
+			children = append(children, fmt.Sprintf(
+				"case %q:\nfor _, e := range c.Children {\n%s = append(%s, buildAs[%s](b, e))\n}",
+				f.Name(), x, x, g.typeString(t.Elem())))
+			continue
+		}
+		if g.isNode(f.Type()) {
+			children = append(children, fmt.Sprintf("case %q:\n%s = buildAs[%s](b, c)", f.Name(), x, g.typeString(f.Type())))
+			continue
+		}
+
+		if isPos(f.Type()) {
+			props = append(props, fmt.Sprintf("%s = b.offset(n, %q)", x, f.Name()))
+			continue
+		}
+		_, kind, err := g.property(f.Type(), x)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %v", name, f.Name(), err)
+		}
+		switch typ := g.typeString(f.Type()); {
+		case typ == "token.Token":
+			props = append(props, fmt.Sprintf("%s = b.token(n, %q)", x, f.Name()))
+		case kind == "string" && typ == "string":
+			props = append(props, fmt.Sprintf("%s = b.string(n, %q)", x, f.Name()))
+		case kind == "bool" && typ == "bool":
+			props = append(props, fmt.Sprintf("%s = b.bool(n, %q)", x, f.Name()))
+		case kind == "number":
+			props = append(props, fmt.Sprintf("%s = %s(b.int(n, %q))", x, typ, f.Name()))
+		default:
+			return fmt.Errorf("%s.%s: unsupported type %s", name, f.Name(), typ)
+		}
+	}
+
+	if len(children) > 0 {
+		g.printf("for _, c := range n.Children {\nswitch c.InternalName {\n")
+		for _, c := range children {
+			g.printf("%s\n", c)
+		}
+		g.printf("default:\nb.unexpected(n, c)\n}\n}\n")
+	} else {
+		g.printf("for _, c := range n.Children {\nb.unexpected(n, c)\n}\n")
+	}
+	for _, p := range props {
+		g.printf("%s\n", p)
+	}
+	g.printf("b.setPositions(x, n)\nreturn x\n}\n\n")
+	return nil
+}
+
+// childrenCase writes the case of the children function for the node type
+// name.
+func (g *generator) childrenCase(name string) {
+	g.printf("case *ast.%s:\n", name)
+	for _, f := range g.fields(name) {
+		x := "n." + f.Name()
+		if g.isNode(f.Type()) {
+			g.printf("if %s != nil {\nf(%s)\n}\n", x, x)
+		} else if s, ok := f.Type().(*types.Slice); ok && g.isNode(s.Elem()) {
+			g.printf("for _, e := range %s {\nif e != nil {\nf(e)\n}\n}\n", x)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Limits of the resources a single request can use, so that one
+// pathological file can't take the process down. Zero means no limit.
+var (
+	maxSize  = flag.Int("max-size", 0, "maximum size in bytes of the content of a request")
+	maxDepth = flag.Int("max-depth", 0, "maximum nesting depth of the tree of a request")
+	timeout  = flag.Duration("timeout", 0, "maximum time spent on a request")
+	maxTree  = flag.Int("max-tree", 0, "maximum size in bytes of the JSON tree of a response")
+)
+
+// Codes of the failures of a request that are not caused by its syntax.
+const (
+	codePanic    = "Panic"
+	codeTooLarge = "ContentTooLarge"
+	codeTooDeep  = "NestingTooDeep"
+	codeTimeout  = "Timeout"
+	// codeInvalidRequest is for requests asking for something unknown.
+	codeInvalidRequest = "InvalidRequest"
+)
+
+// requestError makes a request fail regardless of the syntax of its content.
+type requestError struct {
+	Code    string
+	Message string
+	// Stack is the stack trace of a panic.
+	Stack string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+// limits are the limits of a single request.
+type limits struct {
+	maxSize  int
+	maxDepth int
+	timeout  time.Duration
+	maxTree  int
+	// deadline is when the request times out, if it does.
+	deadline time.Time
+}
+
+// requestLimits returns the limits of a request starting now.
+func requestLimits() limits {
+	l := limits{maxSize: *maxSize, maxDepth: *maxDepth, timeout: *timeout, maxTree: *maxTree}
+	if l.timeout > 0 {
+		l.deadline = time.Now().Add(l.timeout)
+	}
+	return l
+}
+
+// checkSize returns an error if the content or the tree of req is larger
+// than maxSize.
+func (l limits) checkSize(req *request) *requestError {
+	if l.maxSize <= 0 {
+		return nil
+	}
+
+	size := len(req.Content) + len(req.Tree)
+	for _, f := range req.Files {
+		size += len(f.Content)
+	}
+	if size > l.maxSize {
+		return &requestError{
+			Code:    codeTooLarge,
+			Message: fmt.Sprintf("content of %d bytes is larger than the maximum of %d", size, l.maxSize),
+		}
+	}
+	return nil
+}
+
+// maxLine returns the maximum length of a request line, or 0 if there's no
+// limit. A valid request can't take more than 6 bytes per byte of content,
+// as \u0000 escapes do, plus the rest of its fields.
+func maxLine() int {
+	if *maxSize <= 0 {
+		return 0
+	}
+	return 6**maxSize + 64<<10
+}
+
+// deadlineCheck is how many nodes are written between checks of the deadline.
+const deadlineCheck = 1024
+
+// limitSink writes into sink the nodes it receives, panicking with a
+// requestError when they go deeper than maxDepth or past the deadline.
+type limitSink struct {
+	sink
+	maxDepth int
+	deadline time.Time
+
+	depth int
+	nodes int
+}
+
+func (s *limitSink) openNode(typ, name string, start, end position) {
+	s.depth++
+	if s.maxDepth > 0 && s.depth > s.maxDepth {
+		panic(&requestError{
+			Code:    codeTooDeep,
+			Message: fmt.Sprintf("tree is deeper than the maximum of %d", s.maxDepth),
+		})
+	}
+
+	s.nodes++
+	if !s.deadline.IsZero() && s.nodes%deadlineCheck == 0 && time.Now().After(s.deadline) {
+		panic(&requestError{
+			Code:    codeTimeout,
+			Message: "deadline exceeded while writing the tree",
+		})
+	}
+
+	s.sink.openNode(typ, name, start, end)
+}
+
+func (s *limitSink) closeNode() {
+	s.depth--
+	s.sink.closeNode()
+}
+
+// limitWriter writes into w, panicking with a requestError when more than
+// max bytes are written.
+type limitWriter struct {
+	w   io.Writer
+	max int
+	n   int
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	lw.n += len(p)
+	if lw.max > 0 && lw.n > lw.max {
+		panic(&requestError{
+			Code:    codeTooLarge,
+			Message: fmt.Sprintf("tree is larger than the maximum of %d bytes", lw.max),
+		})
+	}
+	return lw.w.Write(p)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withLimit sets *limit to value for the rest of the test.
+func withLimit[T any](t *testing.T, limit *T, value T) {
+	old := *limit
+	*limit = value
+	t.Cleanup(func() { *limit = old })
+}
+
+// handle processes req within the configured limits, like respond, without
+// waiting for a request running out of time to finish.
+func handle(req *request) *response {
+	res, _ := respond(req)
+	return res
+}
+
+func checkFailure(t *testing.T, res *response, code string) {
+	t.Helper()
+	if res.Status != "fatal" {
+		t.Fatalf("expected status fatal; got %s", res.Status)
+	}
+	if res.Code != code {
+		t.Fatalf("expected code %s; got %q: %v", code, res.Code, res.Errors)
+	}
+	if res.AST.Root != nil && string(res.AST.Root) != "null" {
+		t.Fatalf("expected no tree; got %s", res.AST.Root)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	res := safely("1", func() *response { panic("boom") })
+	checkFailure(t, res, codePanic)
+	if res.ID != "1" {
+		t.Fatalf("expected ID 1; got %q", res.ID)
+	}
+	if len(res.Errors) != 2 || res.Errors[0] != "panic: boom" {
+		t.Fatalf("expected the panic and its stack; got %q", res.Errors)
+	}
+	if !strings.Contains(res.Errors[1], "TestPanic") {
+		t.Fatalf("expected the stack of the panic; got %s", res.Errors[1])
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	withLimit(t, maxSize, 20)
+
+	checkFailure(t, handle(&request{Content: "package p\n\nvar a, b = 1, 2\n"}), codeTooLarge)
+	checkFailure(t, handle(&request{Files: []*sourceFile{
+		{Name: "a.go", Content: "package p\n\nvar a = 1\n"},
+		{Name: "b.go", Content: "package p\n\nvar b = 1\n"},
+	}}), codeTooLarge)
+
+	if res := handle(&request{Content: "package p"}); res.Status != "ok" {
+		t.Fatalf("expected status ok; got %s: %v", res.Status, res.Errors)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	withLimit(t, maxDepth, 10)
+
+	checkFailure(t, handle(&request{Content: "package p\n\nvar a = ((((((((((1))))))))))"}), codeTooDeep)
+
+	if res := handle(&request{Content: "package p\n\nvar a = 1"}); res.Status != "ok" {
+		t.Fatalf("expected status ok; got %s: %v", res.Status, res.Errors)
+	}
+}
+
+func TestMaxTree(t *testing.T) {
+	withLimit(t, maxTree, 1000)
+
+	content := "package p\n\nvar a = 1" + strings.Repeat(" + 1", 20000)
+	checkFailure(t, handle(&request{Content: content}), codeTooLarge)
+
+	if res := handle(&request{Content: "package p"}); res.Status != "ok" {
+		t.Fatalf("expected status ok; got %s: %v", res.Status, res.Errors)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	withLimit(t, timeout, time.Nanosecond)
+
+	content := "package p\n\nvar a = 1" + strings.Repeat(" + 1", 20000)
+	checkFailure(t, handle(&request{ID: "1", Content: content}), codeTimeout)
+
+	// The request goes on, and can be waited for.
+	res, done := respond(&request{ID: "2", Content: content})
+	checkFailure(t, res, codeTimeout)
+	if done == nil {
+		t.Fatal("expected the request to go on")
+	}
+	<-done
+}
+
+func TestLeadingID(t *testing.T) {
+	tt := []struct {
+		prefix, id string
+	}{
+		{`{"ID":"1","Content":"package`, "1"},
+		{`{"Filename":"a.go","id":"2","Content":"package`, "2"},
+		{`{"Content":"package p","ID":"3"`, "3"},
+		{`{"Content":"package p`, ""},
+		{`{"ID":"trunc`, ""},
+		{`[]`, ""},
+	}
+	for _, tc := range tt {
+		if id := leadingID([]byte(tc.prefix)); id != tc.id {
+			t.Errorf("leadingID(%q) = %q; want %q", tc.prefix, id, tc.id)
+		}
+	}
+}
+
+func TestServeTooLong(t *testing.T) {
+	withLimit(t, maxSize, 10)
+
+	long, _ := json.Marshal(&request{ID: "1", Content: strings.Repeat("\x00", 1<<20)})
+	short, _ := json.Marshal(&request{ID: "2", Content: "package p"})
+	in := bytes.Join([][]byte{long, short}, []byte("\n"))
+
+	var out bytes.Buffer
+	if err := serve(bytes.NewReader(in), &out, 2, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var responses []*response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		res := &response{}
+		if err := dec.Decode(res); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, res)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses; got %d", len(responses))
+	}
+	checkFailure(t, responses[0], codeTooLarge)
+	if responses[0].ID != "1" {
+		t.Fatalf("expected the ID of the long request; got %q", responses[0].ID)
+	}
+	if responses[1].ID != "2" || responses[1].Status != "ok" {
+		t.Fatalf("expected request 2 to be ok; got %s: %s", responses[1].ID, responses[1].Status)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+// addLiteral sets the decoded value of the literal lit, as a property named
+// after its kind:
+//
+//   - StringValue, the unquoted string,
+//   - IntValue, the exact integer in decimal,
+//   - FloatValue, the nearest float64, formatted as briefly as possible,
+//   - RuneValue, the code point of a rune, as a number,
+//   - ImagValue, the imaginary part, formatted as a FloatValue.
+//
+// As a float64 may not hold them, FLOAT and IMAG literals also get their
+// ExactValue: the exact value of the float or of its imaginary part, as an
+// integer or a fraction, or, when the exponent is too large for that, as
+// the hexadecimal mantissa and binary exponent go/constant keeps.
+//
+// Malformed literals, which go/parser keeps as they are, get a DecodeError
+// property set to true instead.
+func addLiteral(lit *ast.BasicLit, s sink) {
+	v := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if v.Kind() == constant.Unknown {
+		s.property("DecodeError", true)
+		return
+	}
+
+	switch lit.Kind {
+	case token.STRING:
+		s.property("StringValue", constant.StringVal(v))
+	case token.INT:
+		s.property("IntValue", v.ExactString())
+	case token.FLOAT:
+		s.property("FloatValue", formatFloat(v))
+		s.property("ExactValue", constant.ToFloat(v).ExactString())
+	case token.CHAR:
+		r, _ := constant.Int64Val(v)
+		s.property("RuneValue", int(r))
+	case token.IMAG:
+		s.property("ImagValue", formatFloat(constant.Imag(v)))
+		s.property("ExactValue", constant.ToFloat(constant.Imag(v)).ExactString())
+	}
+}
+
+// formatFloat returns the nearest float64 to v in its shortest form. Values
+// out of the range of a float64 are rounded to 6 digits instead, as their
+// exact decimal form can take hundreds of them.
+func formatFloat(v constant.Value) string {
+	f, _ := constant.Float64Val(v)
+	if math.IsInf(f, 0) || f == 0 && constant.Sign(v) != 0 {
+		return v.String()
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
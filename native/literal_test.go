@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLiteralValues(t *testing.T) {
+	tt := []struct {
+		lit   string
+		key   string
+		value interface{}
+		exact string
+	}{
+		{`"hello\n"`, "StringValue", "hello\n", ""},
+		{"`a\\nb`", "StringValue", `a\nb`, ""},
+		{`"é\x41"`, "StringValue", "éA", ""},
+		{"0x1F", "IntValue", "31", ""},
+		{"1_000", "IntValue", "1000", ""},
+		{"0o17", "IntValue", "15", ""},
+		{"0b101", "IntValue", "5", ""},
+		{"123456789012345678901234567890", "IntValue", "123456789012345678901234567890", ""},
+		{"1.5e3", "FloatValue", "1500", "1500"},
+		{"0.1", "FloatValue", "0.1", "1/10"},
+		{"0x1p-2", "FloatValue", "0.25", "1/4"},
+		{"1e400", "FloatValue", "1e+400", "1" + strings.Repeat("0", 400)},
+		{"'a'", "RuneValue", 97, ""},
+		{`'\n'`, "RuneValue", 10, ""},
+		{`'😀'`, "RuneValue", 0x1F600, ""},
+		{"2i", "ImagValue", "2", "2"},
+		{"1.5e2i", "ImagValue", "150", "150"},
+		{`"\q"`, "DecodeError", true, ""},
+		{"0x", "DecodeError", true, ""},
+		{"1e", "DecodeError", true, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.lit, func(t *testing.T) {
+			// Malformed literals are kept by go/parser along with an error.
+			res, _ := parse(&request{Content: "package p\n\nvar _ = " + tc.lit})
+			lit := find(res, "BasicLit", "Value", tc.lit)
+			if lit == nil {
+				t.Fatalf("could not find literal")
+			}
+			got := make(map[string]interface{})
+			for k, v := range lit.Properties {
+				if k != "Kind" && k != "Value" {
+					got[k] = v
+				}
+			}
+			want := props(tc.key, tc.value)
+			if tc.exact != "" {
+				want["ExactValue"] = tc.exact
+			}
+			if !cmp.Equal(want, got) {
+				t.Fatalf("different values: %s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -2,156 +2,522 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
-	"reflect"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
 
-	"github.com/sirupsen/logrus"
+var (
+	workers = flag.Int("workers", runtime.NumCPU(), "number of requests processed at the same time")
+	ordered = flag.Bool("ordered", true, "write the responses in the same order as the requests; otherwise they're matched by ID")
 )
 
 func main() {
-	out := json.NewEncoder(os.Stdout)
+	flag.Parse()
+
+	if err := serve(os.Stdin, os.Stdout, *workers, *ordered); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type request struct {
+	// ID is echoed in the response, to match them when they're written out
+	// of order.
+	ID      string
+	Content string
+	// Language is what the content is written in: Go, the default, or one
+	// of the files of a module, go.mod, go.work or go.sum.
+	Language string
+	// Filename is the name of the file being parsed, if known.
+	Filename string
+	// TypeCheck adds the information computed by go/types to the tree.
+	TypeCheck bool
+	// Files, when given, are parsed as a single package instead of Content.
+	Files []*sourceFile
+	// ColumnMode is the unit columns are counted in: byte, the default,
+	// rune or utf16.
+	ColumnMode string
+	// Output is what the response holds: the ast, the default, the lexical
+	// tokens of the content or both.
+	Output string
+	// Action is what's done with the request: parse its content, the
+	// default, format it or print its Tree back as Go code.
+	Action string
+	// Tree is the tree to print, as written in the responses, or a UAST.
+	Tree json.RawMessage `json:",omitempty"`
+	// Simplify applies the simplifications of gofmt -s when formatting.
+	Simplify bool
+	// Fragment is what the content to parse is: a whole file, the default,
+	// an expression (expr), a list of statements (stmts) or of top-level
+	// declarations (decls).
+	Fragment string
+	// PackageClauseOnly and ImportsOnly stop parsing after the package
+	// clause or the imports, for the requests that need nothing else.
+	PackageClauseOnly bool
+	ImportsOnly       bool
+	// DeclarationErrors reports the names declared twice in a scope.
+	DeclarationErrors bool
+	// SkipObjectResolution leaves identifiers unresolved, so the tree has
+	// no NodeID and DeclRef properties, nor Unresolved identifiers.
+	SkipObjectResolution bool
+	// Encoding is how contents are encoded: as they are, utf8, the default,
+	// or in base64, for files that are not valid UTF-8. The content and the
+	// diff of the response are encoded the same way.
+	Encoding string
+}
+
+// parserMode returns the mode of go/parser asked for by req.
+func (req *request) parserMode() parser.Mode {
+	mode := parser.AllErrors | parser.ParseComments
+	if req.PackageClauseOnly {
+		mode |= parser.PackageClauseOnly
+	}
+	if req.ImportsOnly {
+		mode |= parser.ImportsOnly
+	}
+	if req.DeclarationErrors {
+		mode |= parser.DeclarationErrors
+	}
+	if req.SkipObjectResolution {
+		mode |= parser.SkipObjectResolution
+	}
+	return mode
+}
 
-	s := bufio.NewScanner(os.Stdin)
-	for s.Scan() {
-		input := s.Bytes()
-		logrus.Infof("raw request: %s", input)
+// check returns an error if req can't be processed whatever its content.
+func (req *request) check() *requestError {
+	switch req.ColumnMode {
+	case "", byteColumns, runeColumns, utf16Columns:
+	default:
+		return &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("unknown column mode %q", req.ColumnMode),
+		}
+	}
 
-		var req request
-		if err := json.Unmarshal(input, &req); err != nil {
-			logrus.Warningf("could not decode request: %v", err)
-			continue
+	switch req.Language {
+	case "", goLanguage, golangLanguage:
+	case modLanguage, workLanguage, sumLanguage:
+		if len(req.Files) > 0 || req.Fragment != "" && req.Fragment != fileFragment ||
+			req.Action != "" && req.Action != parseAction || req.Output != "" && req.Output != astOutput {
+			return &requestError{
+				Code:    codeInvalidRequest,
+				Message: fmt.Sprintf("%s files can only be parsed into a tree, one at a time", req.Language),
+			}
 		}
+	default:
+		return &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("unknown language %q", req.Language),
+		}
+	}
 
-		if err := out.Encode(handle(&req)); err != nil {
-			logrus.Errorf("could not encode response: %v", err)
+	switch req.Encoding {
+	case "", utf8Encoding, base64Encoding:
+	default:
+		return &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("unknown encoding %q", req.Encoding),
 		}
 	}
 
-	if err := s.Err(); err != nil {
-		log.Fatal(err)
+	switch req.Fragment {
+	case "", fileFragment:
+	case exprFragment, stmtsFragment, declsFragment:
+		if len(req.Files) > 0 {
+			return &requestError{
+				Code:    codeInvalidRequest,
+				Message: "packages can only be parsed from whole files",
+			}
+		}
+	default:
+		return &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("unknown fragment %q", req.Fragment),
+		}
 	}
-}
 
-type request struct {
-	Content  string
-	Language string
+	switch req.Action {
+	case "", parseAction:
+	case printAction:
+		if len(req.Tree) == 0 || string(req.Tree) == "null" {
+			return &requestError{
+				Code:    codeInvalidRequest,
+				Message: "there's no tree to print",
+			}
+		}
+		return nil
+	case formatAction:
+		if len(req.Files) > 0 {
+			return &requestError{
+				Code:    codeInvalidRequest,
+				Message: "only single files can be formatted",
+			}
+		}
+		return nil
+	default:
+		return &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("unknown action %q", req.Action),
+		}
+	}
+
+	switch req.Output {
+	case "", astOutput:
+	case tokensOutput, bothOutput:
+		if len(req.Files) > 0 {
+			return &requestError{
+				Code:    codeInvalidRequest,
+				Message: "tokens are only available for single files",
+			}
+		}
+	default:
+		return &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("unknown output %q", req.Output),
+		}
+	}
+	return nil
 }
 
 type response struct {
+	ID     string `json:",omitempty"`
 	Status string
+	// Code tells why a request failed, when it's not because of its syntax.
+	Code   string `json:",omitempty"`
 	Errors []string
-	AST    interface{}
+	AST    *result
+	// Content is the code printed from a tree, or the formatted content.
+	Content string `json:",omitempty"`
+	// Formatted is set when the content to format was already formatted.
+	Formatted bool `json:",omitempty"`
+	// Diff holds the changes made by formatting, as a unified diff.
+	Diff string `json:",omitempty"`
+}
+
+// result is the AST carried by a response: the root node of the file, its
+// tokens if they were asked for and the syntax errors found while parsing it.
+type result struct {
+	Root   json.RawMessage
+	Tokens []*lexToken   `json:",omitempty"`
+	Errors []*parseError `json:",omitempty"`
 }
 
-func handle(req *request) *response {
-	f, err := parse(req.Content)
+// document writes a tree into a sink. Trees are written straight as JSON
+// instead of being built first, as they can be huge.
+type document func(s sink)
+
+// respond processes req within the configured limits. The parser can't be
+// interrupted, so a request running out of time is answered right away but
+// left to finish in the background, while its conversion stops as soon as it
+// sees the deadline. done is closed once it finishes, or nil if it already
+// has, so that callers can wait for it before starting others.
+func respond(req *request) (res *response, done <-chan struct{}) {
+	l := requestLimits()
+	if l.deadline.IsZero() {
+		return process(req, l), nil
+	}
+
+	results := make(chan *response, 1)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		results <- process(req, l)
+	}()
+
+	t := time.NewTimer(time.Until(l.deadline))
+	defer t.Stop()
+	select {
+	case res := <-results:
+		return res, nil
+	case <-t.C:
+		return failure(req.ID, &requestError{
+			Code:    codeTimeout,
+			Message: fmt.Sprintf("request took longer than %v", l.timeout),
+		}), finished
+	}
+}
+
+// process parses, formats or prints req into a response. Panics and exceeded
+// limits make the request fail with a fatal response instead of taking the
+// process down.
+func process(req *request, l limits) *response {
+	return safely(req.ID, func() *response {
+		if err := req.check(); err != nil {
+			return failure(req.ID, err)
+		}
+		if err := req.decode(); err != nil {
+			return failure(req.ID, err)
+		}
+		if err := l.checkSize(req); err != nil {
+			return failure(req.ID, err)
+		}
+
+		var res *response
+		switch req.Action {
+		case printAction:
+			res = printResponse(req, l)
+		case formatAction:
+			res = formatResponse(req)
+		default:
+			res = parseResponse(req, l)
+		}
+		req.encode(res)
+		return res
+	})
+}
+
+// safely returns the response of f, or the fatal response of the request id
+// if f panics.
+func safely(id string, f func() *response) (res *response) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(*requestError)
+			if !ok {
+				err = &requestError{
+					Code:    codePanic,
+					Message: fmt.Sprintf("panic: %v", r),
+					Stack:   string(debug.Stack()),
+				}
+			}
+			res = failure(id, err)
+		}
+	}()
+	return f()
+}
+
+// parseResponse parses req into a response holding its tree, its tokens or
+// both, and its syntax errors.
+func parseResponse(req *request, l limits) *response {
 	res := &response{
+		ID:     req.ID,
 		Status: "ok",
-		AST:    struct{ Root interface{} }{f},
+		AST:    &result{},
+	}
+
+	var (
+		err   error
+		fatal bool
+	)
+	if req.Output != tokensOutput {
+		var d document
+		d, err = prepare(req)
+		if d != nil {
+			res.AST.Root = render(d, l)
+		}
+		fatal = d == nil
+	}
+	if req.Output == tokensOutput || req.Output == bothOutput {
+		toks, scanErr := scanTokens(req)
+		res.AST.Tokens = toks
+		if req.Output == tokensOutput {
+			// Otherwise the parser already reported the same errors.
+			err = scanErr
+		}
 	}
+
 	if err != nil {
-		res.Status = "fatal"
-		res.Errors = append(res.Errors, err.Error())
+		// A partial tree is still useful, so only fail when there's none.
+		res.Status = "error"
+		if fatal {
+			res.Status = "fatal"
+		}
+		res.Errors = append(res.Errors, errorMessages(err)...)
+		res.AST.Errors = parseErrors(err)
 	}
 	return res
 }
 
-type node struct {
-	InternalType string            `json:",omitempty"`
-	InternalName string            `json:",omitempty"`
-	Properties   map[string]string `json:",omitempty"`
-	Children     []*node           `json:",omitempty"`
-	StartOffset  token.Pos         `json:",omitempty"`
-	EndOffset    token.Pos         `json:",omitempty"`
+// render encodes the tree of d as JSON, within the depth, deadline and tree
+// size of l. The tree is kept until it's complete, so that a request failing
+// halfway still gets a clean response, but never grows past maxTree.
+func render(d document, l limits) json.RawMessage {
+	var buf bytes.Buffer
+	s := newJSONSink(&limitWriter{w: &buf, max: l.maxTree})
+	d(&limitSink{sink: s, maxDepth: l.maxDepth, deadline: l.deadline})
+	s.flush()
+	return buf.Bytes()
 }
 
-func parse(content string) (*node, error) {
-	fs := token.NewFileSet()
-	f, err := parser.ParseFile(fs, "", content, parser.AllErrors)
-	if err != nil {
-		return nil, err
+// failure returns the fatal response of a request failing with err.
+func failure(id string, err *requestError) *response {
+	res := &response{
+		ID:     id,
+		Status: "fatal",
+		Code:   err.Code,
+		Errors: []string{err.Message},
+		AST:    &result{},
 	}
-	return tree(fs, f), nil
+	if err.Stack != "" {
+		res.Errors = append(res.Errors, err.Stack)
+	}
+	return res
 }
 
-func tree(fs *token.FileSet, n ast.Node) *node {
-	v := reflect.ValueOf(n)
-	if v.IsNil() {
-		return nil
+// writeResponse writes res into w as a line of JSON, the same as a
+// json.Encoder would, without going through its tree again.
+func writeResponse(w *bufio.Writer, res *response) error {
+	w.WriteString("{")
+	if res.ID != "" {
+		w.WriteString(`"ID":`)
+		writeJSON(w, res.ID)
+		w.WriteString(",")
+	}
+	w.WriteString(`"Status":`)
+	writeJSON(w, res.Status)
+	if res.Code != "" {
+		w.WriteString(`,"Code":`)
+		writeJSON(w, res.Code)
+	}
+	w.WriteString(`,"Errors":`)
+	writeJSON(w, res.Errors)
+	w.WriteString(`,"AST":`)
+	if res.AST == nil {
+		w.WriteString("null")
+	} else {
+		w.WriteString(`{"Root":`)
+		if res.AST.Root == nil {
+			w.WriteString("null")
+		} else {
+			w.Write(res.AST.Root)
+		}
+		if len(res.AST.Tokens) > 0 {
+			w.WriteString(`,"Tokens":`)
+			writeJSON(w, res.AST.Tokens)
+		}
+		if len(res.AST.Errors) > 0 {
+			w.WriteString(`,"Errors":`)
+			writeJSON(w, res.AST.Errors)
+		}
+		w.WriteString("}")
+	}
+	if res.Content != "" {
+		w.WriteString(`,"Content":`)
+		writeJSON(w, res.Content)
+	}
+	if res.Formatted {
+		w.WriteString(`,"Formatted":true`)
 	}
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+	if res.Diff != "" {
+		w.WriteString(`,"Diff":`)
+		writeJSON(w, res.Diff)
 	}
-	t := v.Type()
+	w.WriteString("}\n")
+	return w.Flush()
+}
 
-	root := &node{
-		InternalType: t.Name(),
-		StartOffset:  n.Pos() - 1,
-		EndOffset:    n.End() - 1,
-		Properties:   make(map[string]string),
+// writeJSON writes v into w as encoding/json does. Write errors are kept by
+// w and returned by its Flush.
+func writeJSON(w io.Writer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// None of the values of a response fail to be encoded.
+		panic(err)
 	}
+	w.Write(b)
+}
 
-	for i := 0; i < t.NumField(); i++ {
-		name := t.Field(i).Name
-		if ignoredFields[name] {
-			continue
-		}
-		field := v.Field(i)
-		value := field.Interface()
+type node struct {
+	InternalType string `json:",omitempty"`
+	InternalName string `json:",omitempty"`
+	// Properties are documented in PROPERTIES.md.
+	Properties  map[string]interface{} `json:",omitempty"`
+	Children    []*node                `json:",omitempty"`
+	StartOffset token.Pos              `json:",omitempty"`
+	EndOffset   token.Pos              `json:",omitempty"`
+	StartLine   int                    `json:",omitempty"`
+	StartColumn int                    `json:",omitempty"`
+	EndLine     int                    `json:",omitempty"`
+	EndColumn   int                    `json:",omitempty"`
+}
 
-		switch v := value.(type) {
-		case ast.Node:
-			if child := tree(fs, v.(ast.Node)); child != nil {
-				child.InternalName = name
-				root.Children = append(root.Children, child)
-			}
-			continue
-		case nil, token.Pos:
-			continue
-		default:
-		}
+// parse is like prepare, but returns the tree of nodes of the document.
+func parse(req *request) (*node, error) {
+	d, err := prepare(req)
+	if d == nil {
+		return nil, err
+	}
+	s := &nodeSink{}
+	d(s)
+	return s.root, err
+}
 
-		switch field.Kind() {
-		case reflect.Slice:
-			if field.Len() == 0 {
-				continue
-			}
-			slice := &node{InternalName: name, InternalType: listTypeName(field)}
-			for i := 0; i < field.Len(); i++ {
-				e := field.Index(i).Interface()
-				if n, ok := e.(ast.Node); ok {
-					slice.Children = append(slice.Children, tree(fs, n))
-				} else {
-					panic(fmt.Sprintf("found slice of non nodes: %T", e))
-				}
-			}
-			root.Children = append(root.Children, slice)
+// prepare parses content as a Go file, or as the fragment of one req asks
+// for. When the file has syntax errors the returned tree is the best effort
+// of the parser, with ast.BadExpr, BadStmt and BadDecl nodes where the code
+// could not be parsed.
+func prepare(req *request) (document, error) {
+	switch {
+	case req.Language == modLanguage, req.Language == workLanguage, req.Language == sumLanguage:
+		return prepareModule(req)
+	case len(req.Files) > 0:
+		return preparePackage(req)
+	case req.Fragment != "" && req.Fragment != fileFragment:
+		return prepareFragment(req)
+	}
 
-		default:
-			root.Properties[name] = fmt.Sprint(field)
-		}
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, req.Filename, req.Content, req.parserMode())
+	if f == nil || !f.Package.IsValid() {
+		// Without a package clause there's no tree worth returning.
+		return nil, err
 	}
 
-	return root
+	ids := declIDs(f)
+	var (
+		pkg  *types.Package
+		info *types.Info
+	)
+	if req.TypeCheck {
+		pkg, info = typeCheck(fs, []*ast.File{f})
+	}
+	return func(s sink) {
+		c := newConverter(s, fs, f, ids)
+		c.filename = req.Filename
+		c.src = req.Content
+		c.columns = columnTable(req.Content, req.ColumnMode)
+		c.pkg, c.info = pkg, info
+		c.file(f)
+	}, err
 }
 
-func listTypeName(v reflect.Value) string {
-	t := v.Type().Elem()
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+// freeComments returns the comment groups in f that are not attached to any
+// node as a Doc or Comment field. The attached ones are already part of the
+// tree under the node they belong to, so they're not repeated in Comments.
+func freeComments(f *ast.File) []*ast.CommentGroup {
+	attached := make(map[*ast.CommentGroup]bool)
+	mark := func(n ast.Node) bool {
+		if cg, ok := n.(*ast.CommentGroup); ok {
+			attached[cg] = true
+			return false
+		}
+		return true
+	}
+	// The comments of the file itself are all of them, so only its
+	// declarations are inspected.
+	if f.Doc != nil {
+		attached[f.Doc] = true
+	}
+	for _, d := range f.Decls {
+		inspect(d, mark)
 	}
-	return "ListOf" + t.Name()
-}
 
-var ignoredFields = map[string]bool{
-	"Imports":    true,
-	"Scope":      true,
-	"Obj":        true,
-	"Unresolved": true,
+	var free []*ast.CommentGroup
+	for _, cg := range f.Comments {
+		if !attached[cg] {
+			free = append(free, cg)
+		}
+	}
+	return free
 }
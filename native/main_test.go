@@ -3,24 +3,25 @@ package main
 import (
 	"go/token"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
-func props(vs ...string) map[string]string {
-	m := make(map[string]string)
+func props(vs ...interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
 	if len(vs)%2 != 0 {
 		log.Fatal("bad props value, list is not even")
 	}
 	for i := 0; i < len(vs); i += 2 {
-		m[vs[i]] = vs[i+1]
+		m[vs[i].(string)] = vs[i+1]
 	}
 	return m
 }
 
-func n(name, typ string, props map[string]string, children ...*node) *node {
+func n(name, typ string, props map[string]interface{}, children ...*node) *node {
 	return &node{
 		InternalName: name,
 		InternalType: typ,
@@ -64,12 +65,12 @@ func TestHandle(t *testing.T) {
 					n("", "GenDecl", props("Tok", "import"),
 						n("Specs", "ListOfSpec", nil,
 							n("", "ImportSpec", nil,
-								n("Path", "BasicLit", props("Kind", "STRING", "Value", "\"fmt\"")),
+								n("Path", "BasicLit", props("Kind", "STRING", "Value", "\"fmt\"", "StringValue", "fmt")),
 							),
 						),
 					),
-					n("", "FuncDecl", nil,
-						n("Name", "Ident", props("Name", "main")),
+					n("", "FuncDecl", props("NodeID", 1),
+						n("Name", "Ident", props("Name", "main", "DeclRef", 1)),
 						n("Type", "FuncType", nil,
 							n("Params", "FieldList", nil),
 						),
@@ -82,7 +83,7 @@ func TestHandle(t *testing.T) {
 											n("Sel", "Ident", props("Name", "Println")),
 										),
 										n("Args", "ListOfExpr", nil,
-											n("", "BasicLit", props("Kind", "STRING", "Value", "\"hello\"")),
+											n("", "BasicLit", props("Kind", "STRING", "Value", "\"hello\"", "StringValue", "hello")),
 										),
 									),
 								),
@@ -90,6 +91,9 @@ func TestHandle(t *testing.T) {
 						),
 					),
 				),
+				n("Unresolved", "ListOfIdent", nil,
+					n("", "Ident", props("Name", "fmt")),
+				),
 			),
 		},
 		{
@@ -103,29 +107,198 @@ func TestHandle(t *testing.T) {
 				n("Decls", "ListOfDecl", nil,
 					n("", "GenDecl", props("Tok", "const"),
 						n("Specs", "ListOfSpec", nil,
-							n("", "ValueSpec", nil,
+							n("", "ValueSpec", props("NodeID", 1),
 								n("Names", "ListOfIdent", nil,
-									n("", "Ident", props("Name", "a")),
+									n("", "Ident", props("Name", "a", "DeclRef", 1)),
 								),
 								n("Values", "ListOfExpr", nil,
 									n("", "BinaryExpr", props("Op", "+"),
-										n("X", "BasicLit", props("Kind", "INT", "Value", "40")),
-										n("Y", "BasicLit", props("Kind", "INT", "Value", "2")),
+										n("X", "BasicLit", props("Kind", "INT", "Value", "40", "IntValue", "40")),
+										n("Y", "BasicLit", props("Kind", "INT", "Value", "2", "IntValue", "2")),
+									),
+								),
+							),
+						),
+					),
+				),
+			),
+		},
+		{
+			name: "comments",
+			content: `
+				// Package comments is documented.
+				package comments
+
+				// A is documented.
+				var A = 1 // trailing
+
+				// free-floating`,
+			ast: n("", "File", nil,
+				n("Doc", "CommentGroup", nil,
+					n("List", "ListOfComment", nil,
+						n("", "Comment", props("Text", "// Package comments is documented.")),
+					),
+				),
+				n("Name", "Ident", props("Name", "comments")),
+				n("Decls", "ListOfDecl", nil,
+					n("", "GenDecl", props("Tok", "var"),
+						n("Doc", "CommentGroup", nil,
+							n("List", "ListOfComment", nil,
+								n("", "Comment", props("Text", "// A is documented.")),
+							),
+						),
+						n("Specs", "ListOfSpec", nil,
+							n("", "ValueSpec", props("NodeID", 1),
+								n("Names", "ListOfIdent", nil,
+									n("", "Ident", props("Name", "A", "DeclRef", 1)),
+								),
+								n("Values", "ListOfExpr", nil,
+									n("", "BasicLit", props("Kind", "INT", "Value", "1", "IntValue", "1")),
+								),
+								n("Comment", "CommentGroup", nil,
+									n("List", "ListOfComment", nil,
+										n("", "Comment", props("Text", "// trailing")),
+									),
+								),
+							),
+						),
+					),
+				),
+				n("Comments", "ListOfCommentGroup", nil,
+					n("", "CommentGroup", nil,
+						n("List", "ListOfComment", nil,
+							n("", "Comment", props("Text", "// free-floating")),
+						),
+					),
+				),
+			),
+		},
+		{
+			name: "syntax error",
+			content: `
+				package main
+				)
+				var b = 1`,
+			err: "3:5: expected declaration, found ')'",
+			ast: n("", "File", nil,
+				n("Name", "Ident", props("Name", "main")),
+				n("Decls", "ListOfDecl", nil,
+					n("", "BadDecl", nil),
+					n("", "GenDecl", props("Tok", "var"),
+						n("Specs", "ListOfSpec", nil,
+							n("", "ValueSpec", props("NodeID", 1),
+								n("Names", "ListOfIdent", nil,
+									n("", "Ident", props("Name", "b", "DeclRef", 1)),
+								),
+								n("Values", "ListOfExpr", nil,
+									n("", "BasicLit", props("Kind", "INT", "Value", "1", "IntValue", "1")),
+								),
+							),
+						),
+					),
+				),
+			),
+		},
+		{
+			name: "generics",
+			content: `
+				package p
+
+				type Set[K comparable, V any] map[K]V
+
+				type Number interface{ ~int | ~float64 }
+
+				var s Set[string, int]`,
+			ast: n("", "File", nil,
+				n("Name", "Ident", props("Name", "p")),
+				n("Decls", "ListOfDecl", nil,
+					n("", "GenDecl", props("Tok", "type"),
+						n("Specs", "ListOfSpec", nil,
+							n("", "TypeSpec", props("NodeID", 1),
+								n("Name", "Ident", props("Name", "Set", "DeclRef", 1)),
+								n("TypeParams", "FieldList", nil,
+									n("List", "ListOfField", nil,
+										n("", "Field", props("NodeID", 2),
+											n("Names", "ListOfIdent", nil,
+												n("", "Ident", props("Name", "K", "DeclRef", 2)),
+											),
+											n("Type", "Ident", props("Name", "comparable")),
+										),
+										n("", "Field", props("NodeID", 3),
+											n("Names", "ListOfIdent", nil,
+												n("", "Ident", props("Name", "V", "DeclRef", 3)),
+											),
+											n("Type", "Ident", props("Name", "any")),
+										),
+									),
+								),
+								n("Type", "MapType", nil,
+									n("Key", "Ident", props("Name", "K", "DeclRef", 2)),
+									n("Value", "Ident", props("Name", "V", "DeclRef", 3)),
+								),
+							),
+						),
+					),
+					n("", "GenDecl", props("Tok", "type"),
+						n("Specs", "ListOfSpec", nil,
+							n("", "TypeSpec", props("NodeID", 4),
+								n("Name", "Ident", props("Name", "Number", "DeclRef", 4)),
+								n("Type", "InterfaceType", props("Incomplete", false),
+									n("Methods", "FieldList", nil,
+										n("List", "ListOfField", nil,
+											n("", "Field", nil,
+												n("Type", "BinaryExpr", props("Op", "|"),
+													n("X", "UnaryExpr", props("Op", "~"),
+														n("X", "Ident", props("Name", "int")),
+													),
+													n("Y", "UnaryExpr", props("Op", "~"),
+														n("X", "Ident", props("Name", "float64")),
+													),
+												),
+											),
+										),
+									),
+								),
+							),
+						),
+					),
+					n("", "GenDecl", props("Tok", "var"),
+						n("Specs", "ListOfSpec", nil,
+							n("", "ValueSpec", props("NodeID", 5),
+								n("Names", "ListOfIdent", nil,
+									n("", "Ident", props("Name", "s", "DeclRef", 5)),
+								),
+								n("Type", "IndexListExpr", nil,
+									n("X", "Ident", props("Name", "Set", "DeclRef", 1)),
+									n("Indices", "ListOfExpr", nil,
+										n("", "Ident", props("Name", "string")),
+										n("", "Ident", props("Name", "int")),
 									),
 								),
 							),
 						),
 					),
 				),
+				n("Unresolved", "ListOfIdent", nil,
+					n("", "Ident", props("Name", "comparable")),
+					n("", "Ident", props("Name", "any")),
+					n("", "Ident", props("Name", "int")),
+					n("", "Ident", props("Name", "float64")),
+					n("", "Ident", props("Name", "string")),
+					n("", "Ident", props("Name", "int")),
+				),
 			),
 		},
 	}
 
-	ignorePos := cmp.Comparer(func(a, b token.Pos) bool { return true })
+	ignorePos := cmp.Options{
+		cmp.Comparer(func(a, b token.Pos) bool { return true }),
+		cmpopts.IgnoreFields(node{}, "StartLine", "StartColumn", "EndLine", "EndColumn"),
+	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := parse(tc.content)
+			res, err := parse(&request{Content: tc.content})
 			if tc.err == "" && err != nil {
 				t.Fatalf("unexpected error: %s", err)
 			}
@@ -138,3 +311,122 @@ func TestHandle(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentOffsets(t *testing.T) {
+	const content = "package p // p is a package"
+
+	res, err := parse(&request{Content: content})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res.Children) != 2 {
+		t.Fatalf("expected Name and Comments; got %d children", len(res.Children))
+	}
+	c := res.Children[1].Children[0].Children[0].Children[0]
+	if c.InternalType != "Comment" {
+		t.Fatalf("expected Comment; got %s", c.InternalType)
+	}
+	if got := content[c.StartOffset:c.EndOffset]; got != "// p is a package" {
+		t.Fatalf("wrong offsets for comment: %q", got)
+	}
+}
+
+func TestHandleStatus(t *testing.T) {
+	tt := []struct {
+		name    string
+		content string
+		status  string
+		root    bool
+	}{
+		{name: "valid file", content: "package main", status: "ok", root: true},
+		{name: "partial file", content: "package main\n)", status: "error", root: true},
+		{name: "no package clause", content: "", status: "fatal", root: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res := handle(&request{Content: tc.content})
+			if res.Status != tc.status {
+				t.Fatalf("expected status %q; got %q", tc.status, res.Status)
+			}
+			if tc.root != (res.AST.Root != nil) {
+				t.Fatalf("expected root to be present: %v; got %v", tc.root, res.AST.Root)
+			}
+		})
+	}
+}
+
+func TestParserModes(t *testing.T) {
+	const content = `package p
+
+import "fmt"
+
+var x = 1
+
+func f() { fmt.Println(x) }
+`
+
+	tt := []struct {
+		name       string
+		req        *request
+		decls      int
+		imports    bool
+		references bool
+	}{
+		{name: "full", req: &request{}, decls: 3, imports: true, references: true},
+		{name: "package clause only", req: &request{PackageClauseOnly: true}},
+		{name: "imports only", req: &request{ImportsOnly: true}, decls: 1, imports: true},
+		{name: "skip object resolution", req: &request{SkipObjectResolution: true}, decls: 3, imports: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.Content = content
+			res, err := parse(tc.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := find(res, "Ident", "Name", "p"); n == nil {
+				t.Fatalf("package name not found")
+			}
+
+			var decls int
+			if l := find(res, "ListOfDecl", "", ""); l != nil {
+				decls = len(l.Children)
+			}
+			if decls != tc.decls {
+				t.Fatalf("expected %d declarations; got %d", tc.decls, decls)
+			}
+			if got := find(res, "ImportSpec", "", "") != nil; got != tc.imports {
+				t.Errorf("expected imports: %v; got %v", tc.imports, got)
+			}
+			if got := find(res, "Ident", "DeclRef", 1) != nil; got != tc.references {
+				t.Errorf("expected references: %v; got %v", tc.references, got)
+			}
+		})
+	}
+
+	// The files of a package are left unresolved too.
+	res, err := parse(&request{SkipObjectResolution: true, Files: []*sourceFile{
+		{Name: "a.go", Content: "package p\n\nvar a = b"},
+		{Name: "b.go", Content: "package p\n\nvar b = 1"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if find(res, "Ident", "DeclRef", 1) != nil {
+		t.Errorf("expected no references")
+	}
+}
+
+func TestDeclarationErrors(t *testing.T) {
+	const content = "package p\n\nvar x int\n\nvar x string\n"
+
+	if _, err := parse(&request{Content: content}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := parse(&request{Content: content, DeclarationErrors: true})
+	if err == nil || !strings.Contains(err.Error(), "x redeclared") {
+		t.Fatalf("expected x to be redeclared; got %v", err)
+	}
+}
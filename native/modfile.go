@@ -0,0 +1,593 @@
+package main
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Languages a request can be written in, chosen by its Language. Go code is
+// parsed by default, and the rest are the files of Go modules.
+const (
+	goLanguage     = "go"
+	golangLanguage = "golang"
+	modLanguage    = "go.mod"
+	workLanguage   = "go.work"
+	sumLanguage    = "go.sum"
+)
+
+// modDirectives holds the node type of each directive allowed in go.mod and
+// go.work files.
+var modDirectives = map[string]map[string]string{
+	modLanguage: {
+		"module":    "Module",
+		"go":        "Go",
+		"toolchain": "Toolchain",
+		"godebug":   "Godebug",
+		"require":   "Require",
+		"exclude":   "Exclude",
+		"replace":   "Replace",
+		"retract":   "Retract",
+		"tool":      "Tool",
+		"ignore":    "Ignore",
+	},
+	workLanguage: {
+		"go":        "Go",
+		"toolchain": "Toolchain",
+		"godebug":   "Godebug",
+		"use":       "Use",
+		"replace":   "Replace",
+	},
+}
+
+var (
+	goVersionRE = regexp.MustCompile(`^([1-9][0-9]*)\.(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*))?([a-z]+[0-9]+)?$`)
+	// modVersionRE matches semantic versions, and the v1 and v1.2 shorthands
+	// go.mod files accept.
+	modVersionRE = regexp.MustCompile(`^v(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?)?)?$`)
+)
+
+// prepareModule parses the content of req as a go.mod, go.work or go.sum
+// file, as the Go command reads them.
+//
+// The tree of a go.mod or go.work file is rooted at a ModFile or WorkFile
+// node holding a node per directive, such as Require or Replace, in the order
+// they're found. Directives grouped in parentheses are held by a Block node.
+// The paths and versions of each directive are Path and Version nodes, and
+// its comments are kept in the Comments of the root. A go.sum file is a
+// SumFile node holding a Checksum node per line.
+func prepareModule(req *request) (document, error) {
+	p := newModParser(req)
+	if req.Language == sumLanguage {
+		p.parseSum()
+	} else {
+		p.lex()
+		p.parseMod()
+	}
+	return p.write, p.errs.Err()
+}
+
+// modToken is a token of a go.mod or go.work file. Its kind is one of the
+// punctuation characters, or one of the kinds below.
+type modToken struct {
+	kind       byte
+	text       string
+	start, end int
+}
+
+const (
+	modEOL     = '\n'
+	modIdent   = 'a'
+	modString  = '"'
+	modArrow   = '='
+	modComment = '/'
+)
+
+// modStmt is a directive or a block of directives, or a line of a go.sum
+// file.
+type modStmt struct {
+	typ, verb  string
+	start, end int
+	props      []modProp
+	args       []modArg
+	stmts      []*modStmt
+}
+
+type modProp struct {
+	key   string
+	value interface{}
+}
+
+// modArg is a path or version of a directive.
+type modArg struct {
+	typ, name, value string
+	start, end       int
+}
+
+type modParser struct {
+	lang, src string
+	locator
+	toks     []modToken
+	stmts    []*modStmt
+	comments []modToken
+	// seen holds the directives that can only be found once.
+	seen map[string]bool
+	errs scanner.ErrorList
+}
+
+func newModParser(req *request) *modParser {
+	tf := token.NewFileSet().AddFile(req.Filename, -1, len(req.Content))
+	tf.SetLinesForContent([]byte(req.Content))
+	p := &modParser{
+		lang:    req.Language,
+		src:     req.Content,
+		locator: newLocator(tf),
+		seen:    make(map[string]bool),
+	}
+	p.columns = columnTable(req.Content, req.ColumnMode)
+	return p
+}
+
+func (p *modParser) errorf(offset int, format string, args ...interface{}) {
+	p.errs.Add(p.tf.Position(p.tf.Pos(offset)), fmt.Sprintf(format, args...))
+}
+
+// lex splits the content into tokens. Spaces are dropped, but not the line
+// ends, which end directives.
+func (p *modParser) lex() {
+	src := p.src
+	for i := 0; i < len(src); {
+		start, c := i, src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == '\n':
+			i++
+			p.add(modEOL, start, i)
+		case strings.HasPrefix(src[i:], "//"):
+			if i = strings.IndexByte(src[start:], '\n'); i < 0 {
+				i = len(src)
+			} else {
+				i += start
+			}
+			p.add(modComment, start, i)
+		case strings.IndexByte("()[],", c) >= 0:
+			i++
+			p.add(c, start, i)
+		case strings.HasPrefix(src[i:], "=>"):
+			i += 2
+			p.add(modArrow, start, i)
+		case c == '"' || c == '`':
+			i = p.lexString(i)
+			p.add(modString, start, i)
+		default:
+			for i < len(src) && !strings.HasPrefix(src[i:], "//") {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if !isModIdent(r) {
+					break
+				}
+				i += size
+			}
+			if i == start {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				p.errorf(i, "unexpected input character %q", r)
+				i += size
+				continue
+			}
+			p.add(modIdent, start, i)
+		}
+	}
+	p.add(modEOL, len(src), len(src))
+}
+
+func (p *modParser) add(kind byte, start, end int) {
+	p.toks = append(p.toks, modToken{kind: kind, text: p.src[start:end], start: start, end: end})
+}
+
+// lexString returns the end of the quoted string starting at i.
+func (p *modParser) lexString(i int) int {
+	quote := p.src[i]
+	for j := i + 1; j < len(p.src); j++ {
+		switch c := p.src[j]; {
+		case c == quote:
+			return j + 1
+		case c == '\\' && quote == '"':
+			j++
+		case c == '\n' && quote == '"':
+			p.errorf(i, "unterminated string")
+			return j
+		}
+	}
+	p.errorf(i, "unterminated string")
+	return len(p.src)
+}
+
+func isModIdent(r rune) bool {
+	switch r {
+	case ' ', '(', ')', '[', ']', '{', '}', ',':
+		return false
+	}
+	return !unicode.IsSpace(r) && unicode.IsPrint(r)
+}
+
+// parseMod parses the tokens of a go.mod or go.work file, a line at a time.
+func (p *modParser) parseMod() {
+	var (
+		block *modStmt
+		// before holds the comments on the lines right before the
+		// current one.
+		before []modToken
+	)
+	for len(p.toks) > 0 {
+		toks, comment := p.line()
+		if comment != nil {
+			p.comments = append(p.comments, *comment)
+		}
+		if len(toks) == 0 {
+			if comment == nil {
+				before = nil
+			} else {
+				before = append(before, *comment)
+			}
+			continue
+		}
+		first, last := toks[0], toks[len(toks)-1]
+
+		switch {
+		case block != nil && len(toks) == 1 && first.kind == ')':
+			block.end = first.end
+			p.stmts = append(p.stmts, block)
+			block = nil
+		case block != nil:
+			block.stmts = append(block.stmts, p.directive(block.verb, toks, toks, before, comment))
+		case first.kind == modIdent && len(toks) > 1 && toks[1].kind == '(' && (len(toks) == 2 || len(toks) == 3 && last.kind == ')'):
+			b := &modStmt{typ: "Block", verb: first.text, start: first.start, end: last.end, props: []modProp{{"Verb", first.text}}}
+			if _, ok := modDirectives[p.lang][first.text]; !ok {
+				p.errorf(first.start, "unknown block type: %s", first.text)
+			}
+			if len(toks) == 3 {
+				p.stmts = append(p.stmts, b)
+			} else {
+				block = b
+			}
+		default:
+			p.stmts = append(p.stmts, p.directive(first.text, toks, toks[1:], before, comment))
+		}
+		before = nil
+	}
+	if block != nil {
+		p.errorf(len(p.src), "unterminated block")
+		block.end = len(p.src)
+		p.stmts = append(p.stmts, block)
+	}
+}
+
+// line returns the tokens of the next line, but its comment, which is
+// returned apart.
+func (p *modParser) line() ([]modToken, *modToken) {
+	var (
+		toks    []modToken
+		comment *modToken
+	)
+	for i, t := range p.toks {
+		switch t.kind {
+		case modEOL:
+			p.toks = p.toks[i+1:]
+			return toks, comment
+		case modComment:
+			comment = &p.toks[i]
+		default:
+			toks = append(toks, t)
+		}
+	}
+	p.toks = nil
+	return toks, comment
+}
+
+// directive parses the directive verb written as toks, which are the verb,
+// unless it's in a block, followed by args. before and suffix are the
+// comments before the directive and on its line.
+func (p *modParser) directive(verb string, toks, args, before []modToken, suffix *modToken) *modStmt {
+	st := &modStmt{start: toks[0].start, end: toks[len(toks)-1].end}
+
+	typ, ok := modDirectives[p.lang][verb]
+	if !ok {
+		p.errorf(st.start, "unknown directive: %s", verb)
+		st.typ = "BadDirective"
+		return st
+	}
+	st.typ = typ
+	usage := func(format string) *modStmt {
+		p.errorf(st.start, "usage: %s "+format, verb)
+		st.typ, st.props, st.args = "BadDirective", nil, nil
+		return st
+	}
+	comments := append(before[:len(before):len(before)], optional(suffix)...)
+
+	switch verb {
+	case "module":
+		if len(args) != 1 || !p.addPath(st, args[0], "Path") {
+			return usage("module/path")
+		}
+		if d := deprecation(comments); d != "" {
+			st.props = append(st.props, modProp{"Deprecated", d})
+		}
+	case "go", "toolchain":
+		if len(args) != 1 || !p.addVersion(st, args[0], "Version", verb) {
+			return usage("version")
+		}
+	case "godebug":
+		if len(args) != 1 || args[0].kind != modIdent || !strings.Contains(args[0].text, "=") {
+			return usage("key=value")
+		}
+		k, v, _ := strings.Cut(args[0].text, "=")
+		st.props = append(st.props, modProp{"Key", k}, modProp{"Value", v})
+	case "require", "exclude":
+		if len(args) != 2 || !p.addPath(st, args[0], "Path") || !p.addVersion(st, args[1], "Version", verb) {
+			return usage("module/path v1.2.3")
+		}
+		if verb == "require" {
+			st.props = append(st.props, modProp{"Indirect", isIndirect(suffix)})
+		}
+	case "replace":
+		if !p.replace(st, args) {
+			return usage("module/path [v1.2.3] => other/module v1.4\n\t or %[1]s module/path [v1.2.3] => ../local/directory")
+		}
+	case "retract":
+		if !p.retract(st, args) {
+			return usage("v1.2.3 or %[1]s [v1.2.3, v1.4.5]")
+		}
+		if r := commentText(comments); r != "" {
+			st.props = append(st.props, modProp{"Rationale", r})
+		}
+	case "use", "tool", "ignore":
+		if len(args) != 1 || !p.addPath(st, args[0], "Path") {
+			return usage("path")
+		}
+	}
+
+	switch verb {
+	case "module", "go", "toolchain":
+		if p.seen[verb] {
+			p.errorf(st.start, "repeated %s statement", verb)
+		}
+		p.seen[verb] = true
+	}
+	return st
+}
+
+// replace adds the paths and versions of a replace directive to st.
+func (p *modParser) replace(st *modStmt, args []modToken) bool {
+	arrow := -1
+	for i, t := range args {
+		if t.kind == modArrow {
+			arrow = i
+			break
+		}
+	}
+	if arrow != 1 && arrow != 2 || len(args)-arrow-1 != 1 && len(args)-arrow-1 != 2 {
+		return false
+	}
+
+	if !p.addPath(st, args[0], "Old") || arrow == 2 && !p.addVersion(st, args[1], "OldVersion", "replace") {
+		return false
+	}
+	if !p.addPath(st, args[arrow+1], "New") {
+		return false
+	}
+	if arrow+2 < len(args) {
+		return p.addVersion(st, args[arrow+2], "NewVersion", "replace")
+	}
+	if dir := st.args[len(st.args)-1].value; !isLocalPath(dir) {
+		p.errorf(args[arrow+1].start, "replacement module without version must be directory path (rooted or starting with ./ or ../)")
+	}
+	return true
+}
+
+// retract adds the version or the interval of versions of a retract
+// directive to st.
+func (p *modParser) retract(st *modStmt, args []modToken) bool {
+	if len(args) == 1 {
+		return p.addVersion(st, args[0], "Version", "retract")
+	}
+	if len(args) != 5 || args[0].kind != '[' || args[2].kind != ',' || args[4].kind != ']' {
+		return false
+	}
+	return p.addVersion(st, args[1], "Low", "retract") && p.addVersion(st, args[3], "High", "retract")
+}
+
+// addPath adds the path written as t to st, with the given name.
+func (p *modParser) addPath(st *modStmt, t modToken, name string) bool {
+	s, ok := p.value(t)
+	if !ok {
+		return false
+	}
+	st.args = append(st.args, modArg{typ: "Path", name: name, value: s, start: t.start, end: t.end})
+	return true
+}
+
+// addVersion adds the version written as t to st, with the given name, if
+// it's a valid version for the directive verb.
+func (p *modParser) addVersion(st *modStmt, t modToken, name, verb string) bool {
+	v, ok := p.value(t)
+	if !ok {
+		return false
+	}
+	switch {
+	case verb == "go" && !goVersionRE.MatchString(v):
+		p.errorf(t.start, "invalid go version '%s': must match format 1.23.0", v)
+	case verb == "toolchain" && v != "default" && !strings.HasPrefix(v, "go1"):
+		p.errorf(t.start, "invalid toolchain version '%s': must match format go1.23.0 or default", v)
+	case verb != "go" && verb != "toolchain" && !modVersionRE.MatchString(v):
+		p.errorf(t.start, "invalid version %q", v)
+	}
+	st.args = append(st.args, modArg{typ: "Version", name: name, value: v, start: t.start, end: t.end})
+	return true
+}
+
+// value returns the text of an identifier or the unquoted string t, or false
+// if it's neither.
+func (p *modParser) value(t modToken) (string, bool) {
+	switch t.kind {
+	case modIdent:
+		return t.text, true
+	case modString:
+		s, err := strconv.Unquote(t.text)
+		if err != nil {
+			p.errorf(t.start, "invalid quoted string: %v", err)
+			return "", false
+		}
+		return s, true
+	}
+	return "", false
+}
+
+// parseSum parses a go.sum file. Each of its lines holds a module path, a
+// version, which ends in /go.mod for the checksums of go.mod files, and a
+// hash.
+func (p *modParser) parseSum() {
+	for start := 0; start < len(p.src); {
+		end := strings.IndexByte(p.src[start:], '\n')
+		if end < 0 {
+			end = len(p.src)
+		} else {
+			end += start
+		}
+		fields := sumFields(p.src[start:end], start)
+		start = end + 1
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			p.errorf(fields[0].start, "malformed go.sum line: wrong number of fields %d", len(fields))
+			continue
+		}
+
+		path, version, hash := fields[0], fields[1], fields[2]
+		v := strings.TrimSuffix(version.text, "/go.mod")
+		p.stmts = append(p.stmts, &modStmt{
+			typ:   "Checksum",
+			start: path.start,
+			end:   hash.end,
+			props: []modProp{{"Hash", hash.text}, {"GoMod", v != version.text}},
+			args: []modArg{
+				{typ: "Path", name: "Path", value: path.text, start: path.start, end: path.end},
+				{typ: "Version", name: "Version", value: v, start: version.start, end: version.end},
+			},
+		})
+	}
+}
+
+// sumFields returns the fields of line, which starts at offset start.
+func sumFields(line string, start int) []modToken {
+	var fields []modToken
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' || line[i] == '\r' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(line) && line[j] != ' ' && line[j] != '\t' && line[j] != '\r' {
+			j++
+		}
+		fields = append(fields, modToken{kind: modIdent, text: line[i:j], start: start + i, end: start + j})
+		i = j
+	}
+	return fields
+}
+
+// write writes the tree of the file into s.
+func (p *modParser) write(s sink) {
+	root := map[string]string{modLanguage: "ModFile", workLanguage: "WorkFile", sumLanguage: "SumFile"}[p.lang]
+	s.openNode(root, "", p.pos(0), p.pos(len(p.src)))
+	for _, st := range p.stmts {
+		p.writeStmt(s, st)
+	}
+	if len(p.comments) > 0 {
+		s.openNode("ListOfComment", "Comments", position{}, position{})
+		for _, c := range p.comments {
+			s.openNode("Comment", "", p.pos(c.start), p.pos(c.end))
+			s.property("Text", c.text)
+			s.closeNode()
+		}
+		s.closeNode()
+	}
+	s.closeNode()
+}
+
+func (p *modParser) writeStmt(s sink, st *modStmt) {
+	s.openNode(st.typ, "", p.pos(st.start), p.pos(st.end))
+	for _, prop := range st.props {
+		s.property(prop.key, prop.value)
+	}
+	for _, a := range st.args {
+		s.openNode(a.typ, a.name, p.pos(a.start), p.pos(a.end))
+		s.property("Value", a.value)
+		s.closeNode()
+	}
+	for _, st := range st.stmts {
+		p.writeStmt(s, st)
+	}
+	s.closeNode()
+}
+
+func (p *modParser) pos(offset int) position {
+	return p.position(p.base + token.Pos(offset))
+}
+
+// isLocalPath tells whether path is a directory in the file system, instead
+// of a module path.
+func isLocalPath(path string) bool {
+	return strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || strings.HasPrefix(path, "/") ||
+		path == "." || path == ".." || strings.HasPrefix(path, `.\`) || strings.HasPrefix(path, `..\`) ||
+		len(path) >= 2 && path[1] == ':'
+}
+
+// isIndirect tells whether comment marks a requirement as indirect.
+func isIndirect(comment *modToken) bool {
+	if comment == nil {
+		return false
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(comment.text, "//"))
+	return text == "indirect" || strings.HasPrefix(text, "indirect;")
+}
+
+func optional(t *modToken) []modToken {
+	if t == nil {
+		return nil
+	}
+	return []modToken{*t}
+}
+
+// commentText returns the text of comments, without their slashes, a line
+// per comment.
+func commentText(comments []modToken) string {
+	var lines []string
+	for _, c := range comments {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(c.text, "//")))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// deprecation returns the message of the paragraph of comments starting
+// with "Deprecated: ", if any.
+func deprecation(comments []modToken) string {
+	var msg []string
+	for _, line := range strings.Split(commentText(comments), "\n") {
+		switch {
+		case msg == nil && strings.HasPrefix(line, "Deprecated:"):
+			msg = append(msg, strings.TrimSpace(strings.TrimPrefix(line, "Deprecated:")))
+		case msg != nil && line == "":
+			return strings.Join(msg, "\n")
+		case msg != nil:
+			msg = append(msg, line)
+		}
+	}
+	return strings.Join(msg, "\n")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testModFile = `// Deprecated: use m/v2.
+module m
+
+go 1.22
+
+require (
+	a.com/x v1.2.3
+	b.com/y v0.1.0 // indirect
+)
+
+replace a.com/x v1.2.3 => ../x
+
+// broken
+retract [v1.0.0, v1.0.5]
+`
+
+func TestParseModFile(t *testing.T) {
+	res, err := parse(&request{Language: "go.mod", Content: testModFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.InternalType != "ModFile" || int(res.EndOffset) != len(testModFile) {
+		t.Fatalf("expected a ModFile spanning the content; got %s to %d", res.InternalType, res.EndOffset)
+	}
+
+	m := find(res, "Module", "", nil)
+	if m == nil || m.Properties["Deprecated"] != "use m/v2." {
+		t.Errorf("expected the module to be deprecated; got %+v", m)
+	}
+	if v := find(res, "Go", "", nil); v == nil || v.Children[0].Properties["Value"] != "1.22" {
+		t.Errorf("expected go 1.22; got %+v", v)
+	}
+
+	b := find(res, "Block", "Verb", "require")
+	if b == nil || len(b.Children) != 2 {
+		t.Fatalf("expected a require block with two requirements; got %+v", b)
+	}
+	if b.Children[0].Properties["Indirect"] != false || b.Children[1].Properties["Indirect"] != true {
+		t.Errorf("expected only the second requirement to be indirect")
+	}
+
+	y := find(res, "Path", "Value", "b.com/y")
+	if want := strings.Index(testModFile, "b.com/y"); y == nil || int(y.StartOffset) != want || y.StartLine != 8 || y.StartColumn != 2 {
+		t.Errorf("expected b.com/y at offset %d, 8:2; got %+v", want, y)
+	}
+
+	r := find(res, "Replace", "", nil)
+	if r == nil || len(r.Children) != 3 || r.Children[1].InternalName != "OldVersion" || r.Children[2].Properties["Value"] != "../x" {
+		t.Errorf("unexpected replace: %+v", r)
+	}
+
+	r = find(res, "Retract", "Rationale", "broken")
+	if r == nil || len(r.Children) != 2 || r.Children[0].InternalName != "Low" || r.Children[1].InternalName != "High" {
+		t.Errorf("unexpected retract: %+v", r)
+	}
+
+	if c := find(res, "ListOfComment", "", nil); c == nil || c.InternalName != "Comments" || len(c.Children) != 3 {
+		t.Errorf("expected the three comments; got %+v", c)
+	}
+}
+
+func TestParseWorkFile(t *testing.T) {
+	res, err := parse(&request{Language: "go.work", Content: "go 1.22\n\nuse (\n\t./a\n\t\"./b c\"\n)\n"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.InternalType != "WorkFile" {
+		t.Fatalf("expected a WorkFile; got %s", res.InternalType)
+	}
+	if find(res, "Path", "Value", "./a") == nil || find(res, "Path", "Value", "./b c") == nil {
+		t.Errorf("expected both directories to be used")
+	}
+
+	r := handle(&request{Language: "go.work", Content: "module m\n"})
+	if r.Status != "error" || !strings.Contains(r.Errors[0], "unknown directive: module") {
+		t.Errorf("expected module to be unknown in go.work files; got %s: %v", r.Status, r.Errors)
+	}
+}
+
+func TestParseSumFile(t *testing.T) {
+	content := "a.com/x v1.2.3 h1:abc=\na.com/x v1.2.3/go.mod h1:def=\n"
+	res, err := parse(&request{Language: "go.sum", Content: content})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.InternalType != "SumFile" || len(res.Children) != 2 {
+		t.Fatalf("expected a SumFile with two checksums; got %+v", res)
+	}
+
+	c := res.Children[1]
+	if c.Properties["Hash"] != "h1:def=" || c.Properties["GoMod"] != true {
+		t.Errorf("unexpected checksum: %+v", c.Properties)
+	}
+	if v := c.Children[1]; v.Properties["Value"] != "v1.2.3" || v.StartLine != 2 || v.StartColumn != 9 {
+		t.Errorf("unexpected version: %+v", v)
+	}
+}
+
+func TestModFileErrors(t *testing.T) {
+	tt := []struct {
+		lang, content, msg string
+		line, column       int
+	}{
+		{"go.mod", "module m\nmodule n\n", "repeated module statement", 2, 1},
+		{"go.mod", "require a.com/x\n", "usage: require module/path v1.2.3", 1, 1},
+		{"go.mod", "go 1.22\nrequire a.com/x 1.0\n", "invalid version \"1.0\"", 2, 17},
+		{"go.mod", "replace a => b\n", "replacement module without version", 1, 14},
+		{"go.mod", "require (\n\ta.com/x v1.0.0\n", "unterminated block", 2, 17},
+		{"go.sum", "a.com/x h1:abc=\n", "wrong number of fields 2", 1, 1},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.content, func(t *testing.T) {
+			res := handle(&request{Language: tc.lang, Content: tc.content})
+			if res.Status != "error" {
+				t.Fatalf("expected status error; got %s: %v", res.Status, res.Errors)
+			}
+			e := res.AST.Errors[0]
+			if !strings.Contains(e.Message, tc.msg) || e.StartLine != tc.line || e.StartColumn != tc.column {
+				t.Errorf("expected %q at %d:%d; got %+v", tc.msg, tc.line, tc.column, e)
+			}
+		})
+	}
+
+	res, err := parse(&request{Language: "go.mod", Content: "require a.com/x\n"})
+	if err == nil || find(res, "BadDirective", "", nil) == nil {
+		t.Errorf("expected a BadDirective; got %v", err)
+	}
+}
+
+func TestInvalidLanguage(t *testing.T) {
+	for _, req := range []*request{
+		{Language: "python", Content: "x"},
+		{Language: "go.mod", Content: "module m", Output: "tokens"},
+		{Language: "go.mod", Content: "module m", Action: "format"},
+		{Language: "go.sum", Fragment: "expr", Content: "x"},
+		{Language: "go.work", Files: []*sourceFile{{Name: "go.work", Content: "go 1.22"}}},
+	} {
+		checkFailure(t, handle(req), codeInvalidRequest)
+	}
+
+	for _, lang := range []string{"", "go", "golang"} {
+		if res := handle(&request{Language: lang, Content: "package p"}); res.Status != "ok" {
+			t.Errorf("expected %q to parse Go code; got %s: %v", lang, res.Status, res.Errors)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+)
+
+// sourceFile is one of the files of a multi-file request.
+type sourceFile struct {
+	Name    string
+	Content string
+}
+
+// preparePackage parses the files of req as a single package into a Package
+// node. Its Files hold a File node per file, with declaration references
+// resolved across files, and its Imports the paths imported by any of them.
+// Files without a package clause are left out.
+func preparePackage(req *request) (document, error) {
+	fs := token.NewFileSet()
+
+	var (
+		files []*ast.File
+		names []string
+		srcs  []string
+		errs  scanner.ErrorList
+	)
+	for _, sf := range req.Files {
+		f, err := parser.ParseFile(fs, sf.Name, sf.Content, req.parserMode())
+		if list, ok := err.(scanner.ErrorList); ok {
+			errs = append(errs, list...)
+		} else if err != nil {
+			return nil, err
+		}
+		if f == nil || !f.Package.IsValid() {
+			continue
+		}
+		files = append(files, f)
+		names = append(names, sf.Name)
+		srcs = append(srcs, sf.Content)
+	}
+	if len(files) == 0 {
+		return nil, errs.Err()
+	}
+
+	resolvePackage(files)
+	ids := declIDs(files...)
+
+	var (
+		pkg  *types.Package
+		info *types.Info
+	)
+	if req.TypeCheck {
+		pkg, info = typeCheck(fs, files)
+	}
+
+	return func(s sink) {
+		s.openNode("Package", "", position{}, position{})
+		s.property("Name", files[0].Name.Name)
+		s.property("ConsistentName", consistentName(files))
+
+		s.openNode("ListOfFile", "Files", position{}, position{})
+		for i, f := range files {
+			c := newConverter(s, fs, f, ids)
+			c.filename = names[i]
+			c.src = srcs[i]
+			c.columns = columnTable(srcs[i], req.ColumnMode)
+			c.pkg, c.info = pkg, info
+			c.file(f)
+		}
+		s.closeNode()
+
+		writeImports(s, files)
+		s.closeNode()
+	}, errs.Err()
+}
+
+// consistentName reports whether all files declare the same package name.
+func consistentName(files []*ast.File) bool {
+	for _, f := range files {
+		if f.Name.Name != files[0].Name.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// writeImports writes a list with an Import node per path imported by files,
+// sorted by path, if there are any imports.
+func writeImports(s sink, files []*ast.File) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, f := range files {
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				path = spec.Path.Value
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	sort.Strings(paths)
+
+	s.openNode("ListOfImport", "Imports", position{}, position{})
+	for _, path := range paths {
+		s.openNode("Import", "", position{}, position{})
+		s.property("Path", path)
+		s.closeNode()
+	}
+	s.closeNode()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePackage(t *testing.T) {
+	req := &request{Files: []*sourceFile{
+		{Name: "a.go", Content: "package p\n\nimport \"fmt\"\n\ntype T struct{}\n\nvar _ = fmt.Sprint"},
+		{Name: "b.go", Content: "package p\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc (T) String() string { return fmt.Sprint(os.Args) }"},
+	}}
+
+	res, err := parse(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if res.InternalType != "Package" {
+		t.Fatalf("expected Package; got %s", res.InternalType)
+	}
+	if want := props("Name", "p", "ConsistentName", true); !cmp.Equal(want, res.Properties) {
+		t.Fatalf("different package properties: %s", cmp.Diff(want, res.Properties))
+	}
+
+	files := res.Children[0]
+	if len(files.Children) != 2 {
+		t.Fatalf("expected 2 files; got %d", len(files.Children))
+	}
+	for i, name := range []string{"a.go", "b.go"} {
+		if got := files.Children[i].Properties["Filename"]; got != name {
+			t.Errorf("expected file %d to be %s; got %s", i, name, got)
+		}
+	}
+
+	imports := n("Imports", "ListOfImport", nil,
+		n("", "Import", props("Path", "fmt")),
+		n("", "Import", props("Path", "os")),
+	)
+	if !cmp.Equal(imports, res.Children[1]) {
+		t.Fatalf("different imports: %s", cmp.Diff(imports, res.Children[1]))
+	}
+
+	decl := find(files.Children[0], "TypeSpec", "NodeID", 1)
+	if decl == nil {
+		t.Fatalf("could not find the declaration of T in a.go")
+	}
+	if decl.StartOffset != 30 {
+		t.Errorf("expected T to start at offset 30; got %d", decl.StartOffset)
+	}
+	if ref := find(files.Children[1], "Ident", "Name", "T"); ref == nil || ref.Properties["DeclRef"] != 1 {
+		t.Fatalf("expected T in b.go to refer to its declaration in a.go; got %v", ref)
+	}
+}
+
+func TestParsePackageNames(t *testing.T) {
+	req := &request{Files: []*sourceFile{
+		{Name: "a.go", Content: "package p"},
+		{Name: "b.go", Content: "package q"},
+		{Name: "c.go", Content: "packag r"},
+	}}
+
+	res, err := parse(req)
+	if err == nil {
+		t.Fatalf("expected an error for c.go")
+	}
+	if want := props("Name", "p", "ConsistentName", false); !cmp.Equal(want, res.Properties) {
+		t.Fatalf("different package properties: %s", cmp.Diff(want, res.Properties))
+	}
+	if got := len(res.Children[0].Children); got != 2 {
+		t.Fatalf("expected 2 files; got %d", got)
+	}
+	if got := parseErrors(err)[0].Filename; got != "c.go" {
+		t.Fatalf("expected the error to be in c.go; got %q", got)
+	}
+}
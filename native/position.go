@@ -0,0 +1,212 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"unicode/utf8"
+)
+
+// go/ast computes the position of many nodes through one of their children:
+// a BinaryExpr starts where its X does and ends where its Y does. On deeply
+// nested trees that's both quadratic and recursive, so the converter finds
+// those positions with a loop instead, remembering them for the descendants
+// that share them until they're converted too.
+
+// pos returns n.Pos().
+func (c *converter) pos(n ast.Node) token.Pos {
+	return c.shared(n, c.starts, leftChild, ast.Node.Pos)
+}
+
+// end returns n.End().
+func (c *converter) end(n ast.Node) token.Pos {
+	return c.shared(n, c.ends, rightChild, ast.Node.End)
+}
+
+// shared returns the position of n, which is that of the last node found
+// following next, as given by get. It's remembered in cache for all the
+// nodes in between.
+func (c *converter) shared(n ast.Node, cache map[ast.Node]token.Pos, next func(ast.Node) ast.Node, get func(ast.Node) token.Pos) token.Pos {
+	if p, ok := cache[n]; ok {
+		delete(cache, n)
+		return p
+	}
+
+	var chain []ast.Node
+	for m := next(n); m != nil; m = next(m) {
+		chain = append(chain, n)
+		n = m
+	}
+	p := get(n)
+	if len(chain) > 1 {
+		for _, m := range chain[1:] {
+			cache[m] = p
+		}
+	}
+	return p
+}
+
+// leftChild returns the child n starts with, according to go/ast, or nil.
+func leftChild(n ast.Node) ast.Node {
+	switch n := n.(type) {
+	case *ast.BinaryExpr:
+		return n.X
+	case *ast.CallExpr:
+		return n.Fun
+	case *ast.IndexExpr:
+		return n.X
+	case *ast.IndexListExpr:
+		return n.X
+	case *ast.SelectorExpr:
+		return n.X
+	case *ast.SliceExpr:
+		return n.X
+	case *ast.TypeAssertExpr:
+		return n.X
+	case *ast.KeyValueExpr:
+		return n.Key
+	case *ast.CompositeLit:
+		if n.Type != nil {
+			return n.Type
+		}
+	case *ast.ExprStmt:
+		return n.X
+	case *ast.IncDecStmt:
+		return n.X
+	case *ast.SendStmt:
+		return n.Chan
+	case *ast.AssignStmt:
+		return n.Lhs[0]
+	}
+	return nil
+}
+
+// rightChild returns the child n ends with, according to go/ast, or nil.
+func rightChild(n ast.Node) ast.Node {
+	switch n := n.(type) {
+	case *ast.BinaryExpr:
+		return n.Y
+	case *ast.UnaryExpr:
+		return n.X
+	case *ast.StarExpr:
+		return n.X
+	case *ast.KeyValueExpr:
+		return n.Value
+	case *ast.ArrayType:
+		return n.Elt
+	case *ast.MapType:
+		return n.Value
+	case *ast.ChanType:
+		return n.Value
+	case *ast.ExprStmt:
+		return n.X
+	case *ast.SendStmt:
+		return n.Value
+	case *ast.LabeledStmt:
+		return n.Stmt
+	case *ast.AssignStmt:
+		return n.Rhs[len(n.Rhs)-1]
+	case *ast.IfStmt:
+		if n.Else != nil {
+			return n.Else
+		}
+	}
+	return nil
+}
+
+// position is where a node starts or ends: its offset in the file, and its
+// line and column, counting from 1. Lines and columns are 0 when unknown.
+type position struct {
+	offset       token.Pos
+	line, column int
+}
+
+// Units columns are counted in, chosen by the ColumnMode of a request.
+const (
+	byteColumns  = "byte"
+	runeColumns  = "rune"
+	utf16Columns = "utf16"
+)
+
+// locator finds the positions of a file.
+type locator struct {
+	tf *token.File
+	// base is the position of the first byte of the file.
+	base token.Pos
+	// start is the offset of the content in the file, when it's a fragment
+	// parsed within some wrapper. Its positions are relative to it, and
+	// those past limit, in the end of the wrapper, are moved to limit.
+	start int
+	limit token.Pos
+	// columns holds the column of each offset of the file, if they're not
+	// counted in bytes.
+	columns []int32
+}
+
+func newLocator(tf *token.File) locator {
+	return locator{tf: tf, base: token.Pos(tf.Base())}
+}
+
+// position returns the position of p, which must be in the file, or no
+// position at all if p is not valid. Lines ignore //line directives, as
+// offsets do.
+func (l *locator) position(p token.Pos) position {
+	if !p.IsValid() {
+		return position{}
+	}
+	if l.limit.IsValid() && p > l.limit {
+		p = l.limit
+	}
+	pos := position{offset: p - l.base - token.Pos(l.start)}
+
+	fp := l.tf.PositionFor(p, false)
+	pos.line, pos.column = fp.Line, fp.Column
+	if fp.Line == 1 {
+		// The wrapper of a fragment takes no line.
+		pos.column -= l.start
+	}
+	if offset := fp.Offset - l.start; l.columns != nil && offset < len(l.columns) {
+		pos.column = int(l.columns[offset])
+	}
+	return pos
+}
+
+// columnTable returns the column of each byte offset of src, counted in the
+// given units, or nil if they are the same as the byte columns, as go/token
+// counts them.
+func columnTable(src, mode string) []int32 {
+	if mode == "" || mode == byteColumns || isASCII(src) {
+		return nil
+	}
+
+	columns := make([]int32, len(src)+1)
+	col := int32(1)
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		for j := i; j < i+size; j++ {
+			columns[j] = col
+		}
+		i += size
+
+		switch {
+		case r == '\n':
+			col = 1
+		case mode == utf16Columns && r >= 0x10000:
+			// Runes outside the BMP take a surrogate pair. Invalid bytes
+			// decode to a U+FFFD each, which takes one unit.
+			col += 2
+		default:
+			col++
+		}
+	}
+	columns[len(src)] = col
+	return columns
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestLineColumn(t *testing.T) {
+	// The literal takes 8 bytes, 4 runes and 5 UTF-16 code units.
+	const content = "package p\n\nvar s = \"é😀\" + x\n"
+
+	tt := []struct {
+		mode           string
+		litEnd, xStart int
+	}{
+		{mode: "", litEnd: 17, xStart: 20},
+		{mode: "byte", litEnd: 17, xStart: 20},
+		{mode: "rune", litEnd: 13, xStart: 16},
+		{mode: "utf16", litEnd: 14, xStart: 17},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.mode, func(t *testing.T) {
+			res, err := parse(&request{Content: content, ColumnMode: tc.mode})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			lit := find(res, "BasicLit", "Kind", "STRING")
+			if lit.StartLine != 3 || lit.StartColumn != 9 || lit.EndLine != 3 || lit.EndColumn != tc.litEnd {
+				t.Fatalf("expected literal at 3:9-3:%d; got %d:%d-%d:%d",
+					tc.litEnd, lit.StartLine, lit.StartColumn, lit.EndLine, lit.EndColumn)
+			}
+			x := find(res, "Ident", "Name", "x")
+			if x.StartLine != 3 || x.StartColumn != tc.xStart {
+				t.Fatalf("expected x at 3:%d; got %d:%d", tc.xStart, x.StartLine, x.StartColumn)
+			}
+		})
+	}
+}
+
+func TestMultiLineNode(t *testing.T) {
+	const content = "package p\n\nfunc f() {\n\tprintln(\"ü\")\n}\n"
+
+	res, err := parse(&request{Content: content, ColumnMode: "rune"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := find(res, "FuncDecl", "", "")
+	if f.StartLine != 3 || f.StartColumn != 1 || f.EndLine != 5 || f.EndColumn != 2 {
+		t.Fatalf("expected function at 3:1-5:2; got %d:%d-%d:%d", f.StartLine, f.StartColumn, f.EndLine, f.EndColumn)
+	}
+}
+
+func TestColumnModeInPackage(t *testing.T) {
+	res, err := parse(&request{ColumnMode: "utf16", Files: []*sourceFile{
+		{Name: "a.go", Content: "package p\n\nvar a = 1"},
+		{Name: "b.go", Content: "package p\n\nvar _ = \"😀\" + a"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a := find(res, "Ident", "Name", "a"); a.StartColumn != 5 {
+		t.Fatalf("expected a at column 5; got %d", a.StartColumn)
+	}
+	if ref := find(res, "BinaryExpr", "Op", "+").Children[1]; ref.StartColumn != 16 {
+		t.Fatalf("expected reference to a at column 16; got %d", ref.StartColumn)
+	}
+}
+
+func TestInvalidColumnMode(t *testing.T) {
+	res := handle(&request{Content: "package p", ColumnMode: "bytes"})
+	if res.Status != "fatal" || res.Code != codeInvalidRequest {
+		t.Fatalf("expected fatal %s; got %s %s", codeInvalidRequest, res.Status, res.Code)
+	}
+}
+
+func TestInvalidPosition(t *testing.T) {
+	// The second file of a package, as a fragment in its wrapper.
+	fs := token.NewFileSet()
+	fs.AddFile("a.go", -1, 10)
+	l := newLocator(fs.AddFile("b.go", -1, 10))
+	l.start = 3
+
+	if p := l.position(token.NoPos); p != (position{}) {
+		t.Fatalf("expected no position; got %+v", p)
+	}
+	if p := l.position(l.base + 4); p.offset != 1 || p.line != 1 || p.column != 2 {
+		t.Fatalf("expected offset 1 at 1:2; got %+v", p)
+	}
+}
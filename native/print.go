@@ -0,0 +1,547 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// What a request asks for, chosen by its Action.
+const (
+	parseAction  = "parse"
+	formatAction = "format"
+	printAction  = "print"
+)
+
+// printerConfig prints code the same way gofmt does.
+var printerConfig = printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+
+// treeNode is a node of a tree to be printed: either a node of the native
+// tree or a UAST node, as the driver writes them.
+type treeNode struct {
+	InternalType string
+	InternalName string
+	Properties   map[string]interface{}
+	Children     []*treeNode
+	StartOffset  int
+	EndOffset    int
+	StartLine    int
+	StartColumn  int
+	EndLine      int
+	EndColumn    int
+	// StartPosition and EndPosition are the positions of a UAST node.
+	StartPosition *uastPosition
+	EndPosition   *uastPosition
+}
+
+type uastPosition struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+// normalize turns n and its descendants into native nodes, in case they're
+// UAST nodes, whose name is a property and positions are objects.
+func (n *treeNode) normalize() {
+	if n.InternalName == "" {
+		n.InternalName, _ = n.Properties["InternalName"].(string)
+	}
+	if p := n.StartPosition; p != nil {
+		n.StartOffset, n.StartLine, n.StartColumn = p.Offset, p.Line, p.Col
+	}
+	if p := n.EndPosition; p != nil {
+		n.EndOffset, n.EndLine, n.EndColumn = p.Offset, p.Line, p.Col
+	}
+	for _, c := range n.Children {
+		c.normalize()
+	}
+}
+
+// unwrap returns the node the tree root stands for. The UAST of a file is
+// rooted at a node without a type holding the File node, along with its
+// ParseError nodes, so that's its only typed child. Any other root is left
+// as it is.
+func unwrap(root *treeNode) *treeNode {
+	if root.InternalType != "" {
+		return root
+	}
+	var typed *treeNode
+	for _, c := range root.Children {
+		if c.InternalType == "" || c.InternalType == "ParseError" {
+			continue
+		}
+		if typed != nil {
+			return root
+		}
+		typed = c
+	}
+	if typed == nil {
+		return root
+	}
+	return typed
+}
+
+// printResponse prints the tree of req as Go code.
+func printResponse(req *request, l limits) *response {
+	var root treeNode
+	if err := json.Unmarshal(req.Tree, &root); err != nil {
+		return failure(req.ID, &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("invalid tree: %v", err),
+		})
+	}
+	root.normalize()
+	tree := unwrap(&root)
+
+	b := newBuilder(tree, req.Filename, l.maxDepth)
+	n := buildAs[ast.Node](b, tree)
+
+	var buf bytes.Buffer
+	if err := printerConfig.Fprint(&buf, b.fs, n); err != nil {
+		return failure(req.ID, &requestError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("could not print the tree: %v", err),
+		})
+	}
+	return &response{
+		ID:      req.ID,
+		Status:  "ok",
+		AST:     &result{},
+		Content: buf.String(),
+	}
+}
+
+// builder builds the go/ast nodes of a tree. The builder of each node type
+// is generated in print_gen.go.
+//
+// The tree only holds where each node starts and ends, so the positions of
+// the tokens in between, which go/printer uses to lay the code out, are
+// guessed as gofmt would have placed them.
+type builder struct {
+	fs   *token.FileSet
+	tf   *token.File
+	base token.Pos
+	// spans holds the start and end of the nodes with a known position.
+	spans map[ast.Node]span
+	// groups holds all the comment groups found, attached or not.
+	groups []*ast.CommentGroup
+	// comments holds where the comments of the tree are, in order.
+	comments []span
+
+	depth, maxDepth int
+}
+
+type span struct {
+	start, end token.Pos
+}
+
+// newBuilder returns a builder of the tree root, which lays out its lines
+// as the positions of its nodes tell.
+func newBuilder(root *treeNode, filename string, maxDepth int) *builder {
+	size, lines, comments := layout(root)
+	fs := token.NewFileSet()
+	tf := fs.AddFile(filename, -1, size)
+	b := &builder{
+		fs:       fs,
+		tf:       tf,
+		base:     token.Pos(tf.Base()),
+		spans:    make(map[ast.Node]span),
+		maxDepth: maxDepth,
+	}
+	if !tf.SetLines(lines) {
+		b.fail("the lines of the tree are out of order")
+	}
+	for _, c := range comments {
+		b.comments = append(b.comments, span{b.pos(c[0]), b.pos(c[1])})
+	}
+	sort.Slice(b.comments, func(i, j int) bool { return b.comments[i].start < b.comments[j].start })
+	return b
+}
+
+// layout returns the size of the file of the tree root, the offsets its
+// lines start at, as told by the positions of its nodes, and the offsets of
+// its comments. go/printer compares the columns of comments and tokens, so
+// they're only exact when counted in bytes. Lines with no node starting or
+// ending in them are given a single byte, which doesn't move the known ones.
+func layout(root *treeNode) (size int, lines []int, comments [][2]int) {
+	starts := make(map[int]int)
+	var last int
+	add := func(offset, line, column int) {
+		if line <= 0 {
+			return
+		}
+		if offset >= size {
+			size = offset + 1
+		}
+		if column > 0 {
+			offset -= column - 1
+		}
+		if o, ok := starts[line]; !ok || offset < o {
+			starts[line] = offset
+		}
+		if line > last {
+			last = line
+		}
+	}
+
+	stack := []*treeNode{root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = append(stack[:len(stack)-1], n.Children...)
+		add(n.StartOffset, n.StartLine, n.StartColumn)
+		add(n.EndOffset, n.EndLine, n.EndColumn)
+		if n.InternalType == "Comment" && n.StartLine > 0 && n.EndLine > 0 {
+			comments = append(comments, [2]int{n.StartOffset, n.EndOffset})
+		}
+	}
+	if last == 0 {
+		return 1, []int{0}, nil
+	}
+
+	lines = make([]int, last)
+	for line := last; line > 1; line-- {
+		if o, ok := starts[line]; ok {
+			lines[line-1] = o
+		} else {
+			lines[line-1] = lines[line] - 1
+		}
+	}
+	return size, lines, comments
+}
+
+// buildAs returns the node of type T built from n.
+func buildAs[T ast.Node](b *builder, n *treeNode) T {
+	b.depth++
+	if b.maxDepth > 0 && b.depth > b.maxDepth {
+		panic(&requestError{
+			Code:    codeTooDeep,
+			Message: fmt.Sprintf("tree is deeper than the maximum of %d", b.maxDepth),
+		})
+	}
+	x, ok := b.build(n).(T)
+	if !ok {
+		b.fail("%s %s can't be a %s", n.InternalType, n.InternalName, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	b.depth--
+
+	if start, end := b.start(n), b.end(n); start.IsValid() && end.IsValid() {
+		b.spans[x] = span{start, end}
+	}
+	return x
+}
+
+// fail makes the request fail because of an invalid tree.
+func (b *builder) fail(format string, args ...interface{}) {
+	panic(&requestError{
+		Code:    codeInvalidRequest,
+		Message: "invalid tree: " + fmt.Sprintf(format, args...),
+	})
+}
+
+// extraChildren are the children of nodes that are read out of the
+// nodes themselves, instead of being one of their fields.
+var extraChildren = map[string]string{
+	"BasicLit":   "ListOfStructTagKey",
+	"Comment":    "Directive",
+	"ImportSpec": "CgoPreamble",
+}
+
+// unexpected fails because c isn't a field of n, unless it's one of its
+// extraChildren.
+func (b *builder) unexpected(n, c *treeNode) {
+	if extraChildren[n.InternalType] == c.InternalType {
+		return
+	}
+	b.fail("%s has no field %q", n.InternalType, c.InternalName)
+}
+
+// pos returns the position of the offset in the file.
+func (b *builder) pos(offset int) token.Pos {
+	if offset < 0 || offset > b.tf.Size() {
+		b.fail("offset %d is out of the file", offset)
+	}
+	return b.base + token.Pos(offset)
+}
+
+// start and end return where n starts and ends, if known.
+func (b *builder) start(n *treeNode) token.Pos {
+	if n.StartLine <= 0 {
+		return token.NoPos
+	}
+	return b.pos(n.StartOffset)
+}
+
+func (b *builder) end(n *treeNode) token.Pos {
+	if n.EndLine <= 0 {
+		return token.NoPos
+	}
+	return b.pos(n.EndOffset)
+}
+
+// startOf and endOf return where the built node x starts and ends, if known.
+func (b *builder) startOf(x ast.Node) token.Pos {
+	return b.spans[x].start
+}
+
+func (b *builder) endOf(x ast.Node) token.Pos {
+	return b.spans[x].end
+}
+
+// skipComments returns p moved past the comments starting right at p or
+// after a space, so that the tokens following them are not placed before.
+func (b *builder) skipComments(p token.Pos) token.Pos {
+	if !p.IsValid() {
+		return p
+	}
+	for {
+		i := sort.Search(len(b.comments), func(i int) bool { return b.comments[i].start >= p })
+		if i == len(b.comments) || b.comments[i].start > p+1 {
+			return p
+		}
+		p = b.comments[i].end
+	}
+}
+
+// shift returns p moved by n bytes, if it's known.
+func shift(p token.Pos, n int) token.Pos {
+	if !p.IsValid() {
+		return token.NoPos
+	}
+	return p + token.Pos(n)
+}
+
+// Properties are read either as the JSON values of the native tree or as
+// the strings of the UAST.
+
+func (b *builder) string(n *treeNode, key string) string {
+	switch v := n.Properties[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	b.fail("%s of %s is not a string", key, n.InternalType)
+	return ""
+}
+
+func (b *builder) bool(n *treeNode, key string) bool {
+	switch v := n.Properties[key].(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		if x, err := strconv.ParseBool(v); err == nil {
+			return x
+		}
+	}
+	b.fail("%s of %s is not a boolean", key, n.InternalType)
+	return false
+}
+
+func (b *builder) int(n *treeNode, key string) int {
+	switch v := n.Properties[key].(type) {
+	case nil:
+		return 0
+	case float64:
+		if v == float64(int(v)) {
+			return int(v)
+		}
+	case string:
+		if x, err := strconv.Atoi(v); err == nil {
+			return x
+		}
+	}
+	b.fail("%s of %s is not an integer", key, n.InternalType)
+	return 0
+}
+
+// offset returns the position at the offset in the property key, if any.
+func (b *builder) offset(n *treeNode, key string) token.Pos {
+	if _, ok := n.Properties[key]; !ok {
+		return token.NoPos
+	}
+	return b.pos(b.int(n, key))
+}
+
+// tokens maps the tokens to their text, as go/token writes them.
+var tokens = func() map[string]token.Token {
+	m := make(map[string]token.Token)
+	for t := token.ILLEGAL; t <= token.TILDE; t++ {
+		m[t.String()] = t
+	}
+	return m
+}()
+
+func (b *builder) token(n *treeNode, key string) token.Token {
+	s := b.string(n, key)
+	if s == "" {
+		return token.ILLEGAL
+	}
+	t, ok := tokens[s]
+	if !ok {
+		b.fail("%s of %s is an unknown token %q", key, n.InternalType, s)
+	}
+	return t
+}
+
+// setPositions sets the positions of the tokens of x, built from n, once all
+// its children are built.
+func (b *builder) setPositions(x ast.Node, n *treeNode) {
+	start, end := b.start(n), b.end(n)
+	last := shift(end, -1)
+
+	switch x := x.(type) {
+	case *ast.Comment:
+		x.Slash = start
+	case *ast.CommentGroup:
+		b.groups = append(b.groups, x)
+	case *ast.FieldList:
+		if len(x.List) == 0 || start < b.startOf(x.List[0]) {
+			x.Opening = start
+		}
+		if len(x.List) == 0 || end > b.endOf(x.List[len(x.List)-1]) {
+			x.Closing = last
+		}
+
+	case *ast.BadExpr:
+		x.From, x.To = start, end
+	case *ast.Ident:
+		x.NamePos = start
+	case *ast.Ellipsis:
+		x.Ellipsis = start
+	case *ast.BasicLit:
+		x.ValuePos = start
+	case *ast.CompositeLit:
+		x.Lbrace, x.Rbrace = start, last
+		if x.Type != nil {
+			x.Lbrace = b.endOf(x.Type)
+		}
+	case *ast.ParenExpr:
+		x.Lparen, x.Rparen = start, last
+	case *ast.IndexExpr:
+		x.Lbrack, x.Rbrack = b.endOf(x.X), last
+	case *ast.IndexListExpr:
+		x.Lbrack, x.Rbrack = b.endOf(x.X), last
+	case *ast.SliceExpr:
+		x.Lbrack, x.Rbrack = b.endOf(x.X), last
+	case *ast.TypeAssertExpr:
+		x.Lparen, x.Rparen = shift(b.endOf(x.X), 1), last
+	case *ast.CallExpr:
+		x.Lparen, x.Rparen = b.endOf(x.Fun), last
+	case *ast.StarExpr:
+		x.Star = start
+	case *ast.UnaryExpr:
+		x.OpPos = start
+	case *ast.BinaryExpr:
+		x.OpPos = shift(b.skipComments(b.endOf(x.X)), 1)
+	case *ast.KeyValueExpr:
+		x.Colon = b.skipComments(b.endOf(x.Key))
+	case *ast.ArrayType:
+		x.Lbrack = start
+	case *ast.StructType:
+		x.Struct = start
+	case *ast.FuncType:
+		if x.Params == nil {
+			x.Params = &ast.FieldList{}
+		}
+		first := ast.Node(x.Params)
+		if x.TypeParams != nil {
+			first = x.TypeParams
+		}
+		if start < b.startOf(first) {
+			x.Func = start
+		}
+	case *ast.InterfaceType:
+		x.Interface = start
+	case *ast.MapType:
+		x.Map = start
+	case *ast.ChanType:
+		x.Begin = start
+		switch x.Dir {
+		case ast.RECV:
+			x.Arrow = start
+		case ast.SEND:
+			x.Arrow = shift(start, len("chan"))
+		}
+
+	case *ast.BadStmt:
+		x.From, x.To = start, end
+	case *ast.EmptyStmt:
+		x.Semicolon = start
+	case *ast.LabeledStmt:
+		x.Colon = b.skipComments(b.endOf(x.Label))
+	case *ast.SendStmt:
+		x.Arrow = shift(b.skipComments(b.endOf(x.Chan)), 1)
+	case *ast.IncDecStmt:
+		x.TokPos = b.skipComments(b.endOf(x.X))
+	case *ast.AssignStmt:
+		if len(x.Lhs) > 0 {
+			x.TokPos = shift(b.skipComments(b.endOf(x.Lhs[len(x.Lhs)-1])), 1)
+		}
+	case *ast.GoStmt:
+		x.Go = start
+	case *ast.DeferStmt:
+		x.Defer = start
+	case *ast.ReturnStmt:
+		x.Return = start
+	case *ast.BranchStmt:
+		x.TokPos = start
+	case *ast.BlockStmt:
+		x.Lbrace, x.Rbrace = start, last
+	case *ast.IfStmt:
+		x.If = start
+	case *ast.CaseClause:
+		x.Case = start
+		if len(x.List) > 0 {
+			x.Colon = b.skipComments(b.endOf(x.List[len(x.List)-1]))
+		} else {
+			x.Colon = shift(start, len("default"))
+		}
+	case *ast.SwitchStmt:
+		x.Switch = start
+	case *ast.TypeSwitchStmt:
+		x.Switch = start
+	case *ast.CommClause:
+		x.Case = start
+		if x.Comm != nil {
+			x.Colon = b.skipComments(b.endOf(x.Comm))
+		} else {
+			x.Colon = shift(start, len("default"))
+		}
+	case *ast.SelectStmt:
+		x.Select = start
+	case *ast.ForStmt:
+		x.For = start
+	case *ast.RangeStmt:
+		x.For = start
+		if x.Value != nil {
+			x.TokPos = shift(b.skipComments(b.endOf(x.Value)), 1)
+		} else if x.Key != nil {
+			x.TokPos = shift(b.skipComments(b.endOf(x.Key)), 1)
+		}
+		x.Range = shift(b.startOf(x.X), -len("range "))
+
+	case *ast.BadDecl:
+		x.From, x.To = start, end
+	case *ast.GenDecl:
+		x.TokPos = start
+		if x.Lparen.IsValid() {
+			x.Rparen = last
+		}
+	case *ast.File:
+		x.Package = start
+		x.FileStart, x.FileEnd = b.base, b.base+token.Pos(b.tf.Size())
+		// go/printer only prints the comments of the file, in order.
+		sort.SliceStable(b.groups, func(i, j int) bool {
+			return b.groups[i].Pos() < b.groups[j].Pos()
+		})
+		x.Comments = b.groups
+	}
+}
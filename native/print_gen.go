@@ -0,0 +1,1033 @@
+// Code generated by gen_convert.go from go/ast; DO NOT EDIT.
+
+package main
+
+import "go/ast"
+
+// build returns the go/ast node of n.
+func (b *builder) build(n *treeNode) ast.Node {
+	switch n.InternalType {
+	case "ArrayType":
+		return b.buildArrayType(n)
+	case "AssignStmt":
+		return b.buildAssignStmt(n)
+	case "BadDecl":
+		return b.buildBadDecl(n)
+	case "BadExpr":
+		return b.buildBadExpr(n)
+	case "BadStmt":
+		return b.buildBadStmt(n)
+	case "BasicLit":
+		return b.buildBasicLit(n)
+	case "BinaryExpr":
+		return b.buildBinaryExpr(n)
+	case "BlockStmt":
+		return b.buildBlockStmt(n)
+	case "BranchStmt":
+		return b.buildBranchStmt(n)
+	case "CallExpr":
+		return b.buildCallExpr(n)
+	case "CaseClause":
+		return b.buildCaseClause(n)
+	case "ChanType":
+		return b.buildChanType(n)
+	case "CommClause":
+		return b.buildCommClause(n)
+	case "Comment":
+		return b.buildComment(n)
+	case "CommentGroup":
+		return b.buildCommentGroup(n)
+	case "CompositeLit":
+		return b.buildCompositeLit(n)
+	case "DeclStmt":
+		return b.buildDeclStmt(n)
+	case "DeferStmt":
+		return b.buildDeferStmt(n)
+	case "Ellipsis":
+		return b.buildEllipsis(n)
+	case "EmptyStmt":
+		return b.buildEmptyStmt(n)
+	case "ExprStmt":
+		return b.buildExprStmt(n)
+	case "Field":
+		return b.buildField(n)
+	case "FieldList":
+		return b.buildFieldList(n)
+	case "File":
+		return b.buildFile(n)
+	case "ForStmt":
+		return b.buildForStmt(n)
+	case "FuncDecl":
+		return b.buildFuncDecl(n)
+	case "FuncLit":
+		return b.buildFuncLit(n)
+	case "FuncType":
+		return b.buildFuncType(n)
+	case "GenDecl":
+		return b.buildGenDecl(n)
+	case "GoStmt":
+		return b.buildGoStmt(n)
+	case "Ident":
+		return b.buildIdent(n)
+	case "IfStmt":
+		return b.buildIfStmt(n)
+	case "ImportSpec":
+		return b.buildImportSpec(n)
+	case "IncDecStmt":
+		return b.buildIncDecStmt(n)
+	case "IndexExpr":
+		return b.buildIndexExpr(n)
+	case "IndexListExpr":
+		return b.buildIndexListExpr(n)
+	case "InterfaceType":
+		return b.buildInterfaceType(n)
+	case "KeyValueExpr":
+		return b.buildKeyValueExpr(n)
+	case "LabeledStmt":
+		return b.buildLabeledStmt(n)
+	case "MapType":
+		return b.buildMapType(n)
+	case "ParenExpr":
+		return b.buildParenExpr(n)
+	case "RangeStmt":
+		return b.buildRangeStmt(n)
+	case "ReturnStmt":
+		return b.buildReturnStmt(n)
+	case "SelectStmt":
+		return b.buildSelectStmt(n)
+	case "SelectorExpr":
+		return b.buildSelectorExpr(n)
+	case "SendStmt":
+		return b.buildSendStmt(n)
+	case "SliceExpr":
+		return b.buildSliceExpr(n)
+	case "StarExpr":
+		return b.buildStarExpr(n)
+	case "StructType":
+		return b.buildStructType(n)
+	case "SwitchStmt":
+		return b.buildSwitchStmt(n)
+	case "TypeAssertExpr":
+		return b.buildTypeAssertExpr(n)
+	case "TypeSpec":
+		return b.buildTypeSpec(n)
+	case "TypeSwitchStmt":
+		return b.buildTypeSwitchStmt(n)
+	case "UnaryExpr":
+		return b.buildUnaryExpr(n)
+	case "ValueSpec":
+		return b.buildValueSpec(n)
+	}
+	b.fail("unknown node type %q", n.InternalType)
+	return nil
+}
+
+func (b *builder) buildArrayType(n *treeNode) *ast.ArrayType {
+	x := &ast.ArrayType{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Len":
+			x.Len = buildAs[ast.Expr](b, c)
+		case "Elt":
+			x.Elt = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildAssignStmt(n *treeNode) *ast.AssignStmt {
+	x := &ast.AssignStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Lhs":
+			for _, e := range c.Children {
+				x.Lhs = append(x.Lhs, buildAs[ast.Expr](b, e))
+			}
+		case "Rhs":
+			for _, e := range c.Children {
+				x.Rhs = append(x.Rhs, buildAs[ast.Expr](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Tok = b.token(n, "Tok")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBadDecl(n *treeNode) *ast.BadDecl {
+	x := &ast.BadDecl{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBadExpr(n *treeNode) *ast.BadExpr {
+	x := &ast.BadExpr{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBadStmt(n *treeNode) *ast.BadStmt {
+	x := &ast.BadStmt{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBasicLit(n *treeNode) *ast.BasicLit {
+	x := &ast.BasicLit{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	x.Kind = b.token(n, "Kind")
+	x.Value = b.string(n, "Value")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBinaryExpr(n *treeNode) *ast.BinaryExpr {
+	x := &ast.BinaryExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Y":
+			x.Y = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Op = b.token(n, "Op")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBlockStmt(n *treeNode) *ast.BlockStmt {
+	x := &ast.BlockStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "List":
+			for _, e := range c.Children {
+				x.List = append(x.List, buildAs[ast.Stmt](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildBranchStmt(n *treeNode) *ast.BranchStmt {
+	x := &ast.BranchStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Label":
+			x.Label = buildAs[*ast.Ident](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Tok = b.token(n, "Tok")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildCallExpr(n *treeNode) *ast.CallExpr {
+	x := &ast.CallExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Fun":
+			x.Fun = buildAs[ast.Expr](b, c)
+		case "Args":
+			for _, e := range c.Children {
+				x.Args = append(x.Args, buildAs[ast.Expr](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Ellipsis = b.offset(n, "Ellipsis")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildCaseClause(n *treeNode) *ast.CaseClause {
+	x := &ast.CaseClause{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "List":
+			for _, e := range c.Children {
+				x.List = append(x.List, buildAs[ast.Expr](b, e))
+			}
+		case "Body":
+			for _, e := range c.Children {
+				x.Body = append(x.Body, buildAs[ast.Stmt](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildChanType(n *treeNode) *ast.ChanType {
+	x := &ast.ChanType{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Value":
+			x.Value = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Dir = ast.ChanDir(b.int(n, "Dir"))
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildCommClause(n *treeNode) *ast.CommClause {
+	x := &ast.CommClause{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Comm":
+			x.Comm = buildAs[ast.Stmt](b, c)
+		case "Body":
+			for _, e := range c.Children {
+				x.Body = append(x.Body, buildAs[ast.Stmt](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildComment(n *treeNode) *ast.Comment {
+	x := &ast.Comment{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	x.Text = b.string(n, "Text")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildCommentGroup(n *treeNode) *ast.CommentGroup {
+	x := &ast.CommentGroup{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "List":
+			for _, e := range c.Children {
+				x.List = append(x.List, buildAs[*ast.Comment](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildCompositeLit(n *treeNode) *ast.CompositeLit {
+	x := &ast.CompositeLit{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Type":
+			x.Type = buildAs[ast.Expr](b, c)
+		case "Elts":
+			for _, e := range c.Children {
+				x.Elts = append(x.Elts, buildAs[ast.Expr](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Incomplete = b.bool(n, "Incomplete")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildDeclStmt(n *treeNode) *ast.DeclStmt {
+	x := &ast.DeclStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Decl":
+			x.Decl = buildAs[ast.Decl](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildDeferStmt(n *treeNode) *ast.DeferStmt {
+	x := &ast.DeferStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Call":
+			x.Call = buildAs[*ast.CallExpr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildEllipsis(n *treeNode) *ast.Ellipsis {
+	x := &ast.Ellipsis{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Elt":
+			x.Elt = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildEmptyStmt(n *treeNode) *ast.EmptyStmt {
+	x := &ast.EmptyStmt{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	x.Implicit = b.bool(n, "Implicit")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildExprStmt(n *treeNode) *ast.ExprStmt {
+	x := &ast.ExprStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildField(n *treeNode) *ast.Field {
+	x := &ast.Field{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Names":
+			for _, e := range c.Children {
+				x.Names = append(x.Names, buildAs[*ast.Ident](b, e))
+			}
+		case "Type":
+			x.Type = buildAs[ast.Expr](b, c)
+		case "Tag":
+			x.Tag = buildAs[*ast.BasicLit](b, c)
+		case "Comment":
+			x.Comment = buildAs[*ast.CommentGroup](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildFieldList(n *treeNode) *ast.FieldList {
+	x := &ast.FieldList{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "List":
+			for _, e := range c.Children {
+				x.List = append(x.List, buildAs[*ast.Field](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildFile(n *treeNode) *ast.File {
+	x := &ast.File{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Name":
+			x.Name = buildAs[*ast.Ident](b, c)
+		case "Decls":
+			for _, e := range c.Children {
+				x.Decls = append(x.Decls, buildAs[ast.Decl](b, e))
+			}
+		case "Unresolved":
+			for _, e := range c.Children {
+				x.Unresolved = append(x.Unresolved, buildAs[*ast.Ident](b, e))
+			}
+		case "Comments":
+			for _, e := range c.Children {
+				x.Comments = append(x.Comments, buildAs[*ast.CommentGroup](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.GoVersion = b.string(n, "GoVersion")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildForStmt(n *treeNode) *ast.ForStmt {
+	x := &ast.ForStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Init":
+			x.Init = buildAs[ast.Stmt](b, c)
+		case "Cond":
+			x.Cond = buildAs[ast.Expr](b, c)
+		case "Post":
+			x.Post = buildAs[ast.Stmt](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildFuncDecl(n *treeNode) *ast.FuncDecl {
+	x := &ast.FuncDecl{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Recv":
+			x.Recv = buildAs[*ast.FieldList](b, c)
+		case "Name":
+			x.Name = buildAs[*ast.Ident](b, c)
+		case "Type":
+			x.Type = buildAs[*ast.FuncType](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildFuncLit(n *treeNode) *ast.FuncLit {
+	x := &ast.FuncLit{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Type":
+			x.Type = buildAs[*ast.FuncType](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildFuncType(n *treeNode) *ast.FuncType {
+	x := &ast.FuncType{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "TypeParams":
+			x.TypeParams = buildAs[*ast.FieldList](b, c)
+		case "Params":
+			x.Params = buildAs[*ast.FieldList](b, c)
+		case "Results":
+			x.Results = buildAs[*ast.FieldList](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildGenDecl(n *treeNode) *ast.GenDecl {
+	x := &ast.GenDecl{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Specs":
+			for _, e := range c.Children {
+				x.Specs = append(x.Specs, buildAs[ast.Spec](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Tok = b.token(n, "Tok")
+	x.Lparen = b.offset(n, "Lparen")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildGoStmt(n *treeNode) *ast.GoStmt {
+	x := &ast.GoStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Call":
+			x.Call = buildAs[*ast.CallExpr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildIdent(n *treeNode) *ast.Ident {
+	x := &ast.Ident{}
+	for _, c := range n.Children {
+		b.unexpected(n, c)
+	}
+	x.Name = b.string(n, "Name")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildIfStmt(n *treeNode) *ast.IfStmt {
+	x := &ast.IfStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Init":
+			x.Init = buildAs[ast.Stmt](b, c)
+		case "Cond":
+			x.Cond = buildAs[ast.Expr](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		case "Else":
+			x.Else = buildAs[ast.Stmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildImportSpec(n *treeNode) *ast.ImportSpec {
+	x := &ast.ImportSpec{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Name":
+			x.Name = buildAs[*ast.Ident](b, c)
+		case "Path":
+			x.Path = buildAs[*ast.BasicLit](b, c)
+		case "Comment":
+			x.Comment = buildAs[*ast.CommentGroup](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildIncDecStmt(n *treeNode) *ast.IncDecStmt {
+	x := &ast.IncDecStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Tok = b.token(n, "Tok")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildIndexExpr(n *treeNode) *ast.IndexExpr {
+	x := &ast.IndexExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Index":
+			x.Index = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildIndexListExpr(n *treeNode) *ast.IndexListExpr {
+	x := &ast.IndexListExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Indices":
+			for _, e := range c.Children {
+				x.Indices = append(x.Indices, buildAs[ast.Expr](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildInterfaceType(n *treeNode) *ast.InterfaceType {
+	x := &ast.InterfaceType{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Methods":
+			x.Methods = buildAs[*ast.FieldList](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Incomplete = b.bool(n, "Incomplete")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildKeyValueExpr(n *treeNode) *ast.KeyValueExpr {
+	x := &ast.KeyValueExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Key":
+			x.Key = buildAs[ast.Expr](b, c)
+		case "Value":
+			x.Value = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildLabeledStmt(n *treeNode) *ast.LabeledStmt {
+	x := &ast.LabeledStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Label":
+			x.Label = buildAs[*ast.Ident](b, c)
+		case "Stmt":
+			x.Stmt = buildAs[ast.Stmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildMapType(n *treeNode) *ast.MapType {
+	x := &ast.MapType{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Key":
+			x.Key = buildAs[ast.Expr](b, c)
+		case "Value":
+			x.Value = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildParenExpr(n *treeNode) *ast.ParenExpr {
+	x := &ast.ParenExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildRangeStmt(n *treeNode) *ast.RangeStmt {
+	x := &ast.RangeStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Key":
+			x.Key = buildAs[ast.Expr](b, c)
+		case "Value":
+			x.Value = buildAs[ast.Expr](b, c)
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Tok = b.token(n, "Tok")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildReturnStmt(n *treeNode) *ast.ReturnStmt {
+	x := &ast.ReturnStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Results":
+			for _, e := range c.Children {
+				x.Results = append(x.Results, buildAs[ast.Expr](b, e))
+			}
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildSelectStmt(n *treeNode) *ast.SelectStmt {
+	x := &ast.SelectStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildSelectorExpr(n *treeNode) *ast.SelectorExpr {
+	x := &ast.SelectorExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Sel":
+			x.Sel = buildAs[*ast.Ident](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildSendStmt(n *treeNode) *ast.SendStmt {
+	x := &ast.SendStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Chan":
+			x.Chan = buildAs[ast.Expr](b, c)
+		case "Value":
+			x.Value = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildSliceExpr(n *treeNode) *ast.SliceExpr {
+	x := &ast.SliceExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Low":
+			x.Low = buildAs[ast.Expr](b, c)
+		case "High":
+			x.High = buildAs[ast.Expr](b, c)
+		case "Max":
+			x.Max = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Slice3 = b.bool(n, "Slice3")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildStarExpr(n *treeNode) *ast.StarExpr {
+	x := &ast.StarExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildStructType(n *treeNode) *ast.StructType {
+	x := &ast.StructType{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Fields":
+			x.Fields = buildAs[*ast.FieldList](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Incomplete = b.bool(n, "Incomplete")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildSwitchStmt(n *treeNode) *ast.SwitchStmt {
+	x := &ast.SwitchStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Init":
+			x.Init = buildAs[ast.Stmt](b, c)
+		case "Tag":
+			x.Tag = buildAs[ast.Expr](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildTypeAssertExpr(n *treeNode) *ast.TypeAssertExpr {
+	x := &ast.TypeAssertExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		case "Type":
+			x.Type = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildTypeSpec(n *treeNode) *ast.TypeSpec {
+	x := &ast.TypeSpec{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Name":
+			x.Name = buildAs[*ast.Ident](b, c)
+		case "TypeParams":
+			x.TypeParams = buildAs[*ast.FieldList](b, c)
+		case "Type":
+			x.Type = buildAs[ast.Expr](b, c)
+		case "Comment":
+			x.Comment = buildAs[*ast.CommentGroup](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Assign = b.offset(n, "Assign")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildTypeSwitchStmt(n *treeNode) *ast.TypeSwitchStmt {
+	x := &ast.TypeSwitchStmt{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Init":
+			x.Init = buildAs[ast.Stmt](b, c)
+		case "Assign":
+			x.Assign = buildAs[ast.Stmt](b, c)
+		case "Body":
+			x.Body = buildAs[*ast.BlockStmt](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildUnaryExpr(n *treeNode) *ast.UnaryExpr {
+	x := &ast.UnaryExpr{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "X":
+			x.X = buildAs[ast.Expr](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	x.Op = b.token(n, "Op")
+	b.setPositions(x, n)
+	return x
+}
+
+func (b *builder) buildValueSpec(n *treeNode) *ast.ValueSpec {
+	x := &ast.ValueSpec{}
+	for _, c := range n.Children {
+		switch c.InternalName {
+		case "Doc":
+			x.Doc = buildAs[*ast.CommentGroup](b, c)
+		case "Names":
+			for _, e := range c.Children {
+				x.Names = append(x.Names, buildAs[*ast.Ident](b, e))
+			}
+		case "Type":
+			x.Type = buildAs[ast.Expr](b, c)
+		case "Values":
+			for _, e := range c.Children {
+				x.Values = append(x.Values, buildAs[ast.Expr](b, e))
+			}
+		case "Comment":
+			x.Comment = buildAs[*ast.CommentGroup](b, c)
+		default:
+			b.unexpected(n, c)
+		}
+	}
+	b.setPositions(x, n)
+	return x
+}
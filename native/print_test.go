@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// printTree parses content and prints its tree back.
+func printTree(t *testing.T, content string) string {
+	t.Helper()
+	res := handle(&request{Content: content})
+	if res.Status != "ok" {
+		t.Fatalf("could not parse: %v", res.Errors)
+	}
+	res = handle(&request{Action: "print", Tree: res.AST.Root})
+	if res.Status != "ok" {
+		t.Fatalf("could not print: %v", res.Errors)
+	}
+	return res.Content
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	var paths []string
+	for _, dir := range []string{"fmt", "go/ast", "go/printer", "net/http", "sort", "strings"} {
+		matches, _ := filepath.Glob(filepath.Join(build.Default.GOROOT, "src", dir, "*.go"))
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Skip("no files in the corpus")
+	}
+
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := format.Source(src)
+		if err != nil {
+			continue
+		}
+		t.Run(strings.TrimPrefix(path, build.Default.GOROOT), func(t *testing.T) {
+			if got := printTree(t, string(src)); got != string(want) {
+				t.Fatalf("different code: %s", cmp.Diff(string(want), got))
+			}
+		})
+	}
+}
+
+func TestPrintSyntax(t *testing.T) {
+	// These only differ in positions that are kept as properties.
+	const content = `package p
+
+import "fmt"
+
+type A = int
+
+var (
+	xs = []any{1, 2}
+)
+
+func f() {
+	fmt.Println(xs...)
+}
+`
+	if got := printTree(t, content); got != content {
+		t.Fatalf("different code: %s", cmp.Diff(content, got))
+	}
+}
+
+// uast turns a native tree into a UAST, as the driver writes it: with the
+// name and all the properties as strings, and positions as objects.
+func uast(v interface{}) interface{} {
+	n, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	props := make(map[string]interface{})
+	native, _ := n["Properties"].(map[string]interface{})
+	for k, p := range native {
+		props[k] = fmt.Sprint(p)
+	}
+	if name, ok := n["InternalName"]; ok {
+		props["InternalName"] = name
+	}
+	u := map[string]interface{}{
+		"InternalType": n["InternalType"],
+		"Properties":   props,
+		"StartPosition": map[string]interface{}{
+			"Offset": n["StartOffset"], "Line": n["StartLine"], "Col": n["StartColumn"],
+		},
+		"EndPosition": map[string]interface{}{
+			"Offset": n["EndOffset"], "Line": n["EndLine"], "Col": n["EndColumn"],
+		},
+	}
+	if children, ok := n["Children"].([]interface{}); ok {
+		var us []interface{}
+		for _, c := range children {
+			us = append(us, uast(c))
+		}
+		u["Children"] = us
+	}
+	return u
+}
+
+func TestPrintUAST(t *testing.T) {
+	const content = "package p\n\n// f does nothing.\nfunc f(a ...int) bool {\n\treturn len(a) > 1_000 // big\n}\n"
+
+	res := handle(&request{Content: content})
+	var tree interface{}
+	if err := json.Unmarshal(res.AST.Root, &tree); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(uast(tree))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res = handle(&request{Action: "print", Tree: b})
+	if res.Status != "ok" {
+		t.Fatalf("could not print: %v", res.Errors)
+	}
+	if res.Content != content {
+		t.Fatalf("different code: %s", cmp.Diff(content, res.Content))
+	}
+}
+
+var update = flag.Bool("update", false, "update the trees in testdata")
+
+// TestPrintDriverUAST prints the UAST the driver makes of testdata/print.go.
+// Its tree is kept in testdata/print.native.json, which the tests of the
+// driver turn into testdata/print.uast.json, with its ToNode and annotations.
+func TestPrintDriverUAST(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "print.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := handle(&request{Content: string(src)})
+	if res.Status != "ok" {
+		t.Fatalf("could not parse: %v", res.Errors)
+	}
+	tree, err := json.Marshal(res.AST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var native bytes.Buffer
+	if err := json.Indent(&native, tree, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	native.WriteString("\n")
+
+	path := filepath.Join("testdata", "print.native.json")
+	if *update {
+		if err := os.WriteFile(path, native.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, err := os.ReadFile(path); err != nil || !bytes.Equal(want, native.Bytes()) {
+		t.Fatalf("%s is out of date, run the tests with -update: %v", path, err)
+	}
+
+	uast, err := os.ReadFile(filepath.Join("testdata", "print.uast.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = handle(&request{Action: "print", Tree: uast})
+	if res.Status != "ok" {
+		t.Fatalf("could not print: %v", res.Errors)
+	}
+	if res.Content != string(src) {
+		t.Fatalf("different code: %s", cmp.Diff(string(src), res.Content))
+	}
+}
+
+func TestPrintWithoutPositions(t *testing.T) {
+	const tree = `{"InternalType": "BinaryExpr", "Properties": {"Op": "+"}, "Children": [
+		{"InternalType": "Ident", "InternalName": "X", "Properties": {"Name": "a"}},
+		{"InternalType": "BasicLit", "InternalName": "Y", "Properties": {"Kind": "INT", "Value": "1"}}
+	]}`
+
+	res := handle(&request{Action: "print", Tree: json.RawMessage(tree)})
+	if res.Status != "ok" || res.Content != "a + 1" {
+		t.Fatalf("expected a + 1; got %s %q %v", res.Status, res.Content, res.Errors)
+	}
+}
+
+func TestPrintInvalidTree(t *testing.T) {
+	tt := []struct {
+		name, tree, err string
+	}{
+		{"no tree", "", "there's no tree to print"},
+		{"null tree", "null", "there's no tree to print"},
+		{"bad JSON", "{", "invalid tree: unexpected end of JSON input"},
+		{"unknown type", `{"InternalType": "Foo"}`, `invalid tree: unknown node type "Foo"`},
+		{"unknown field", `{"InternalType": "Ident", "Children": [{"InternalType": "Ident", "InternalName": "X"}]}`,
+			`invalid tree: Ident has no field "X"`},
+		{"wrong type", `{"InternalType": "ExprStmt", "Children": [{"InternalType": "EmptyStmt", "InternalName": "X"}]}`,
+			"invalid tree: EmptyStmt X can't be a ast.Expr"},
+		{"wrong property", `{"InternalType": "Ident", "Properties": {"Name": 1}}`, "invalid tree: Name of Ident is not a string"},
+		{"unknown token", `{"InternalType": "BinaryExpr", "Properties": {"Op": "<>"}}`,
+			`invalid tree: Op of BinaryExpr is an unknown token "<>"`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res := handle(&request{Action: "print", Tree: json.RawMessage(tc.tree)})
+			checkFailure(t, res, codeInvalidRequest)
+			if res.Errors[0] != tc.err {
+				t.Fatalf("expected error %q; got %q", tc.err, res.Errors[0])
+			}
+		})
+	}
+
+	res := handle(&request{Action: "lint"})
+	checkFailure(t, res, codeInvalidRequest)
+}
@@ -0,0 +1,97 @@
+package main
+
+import "go/ast"
+
+// declIDs assigns an ID to every node declaring an object that some
+// identifier in files resolves to, and to every node a directive attaches
+// to. IDs are numbered in source order, so they're stable for a given
+// content.
+func declIDs(files ...*ast.File) map[ast.Node]int {
+	roots := make([]ast.Node, len(files))
+	for i, f := range files {
+		roots[i] = f
+	}
+	return rootIDs(files, roots)
+}
+
+// rootIDs is like declIDs, but only numbers the nodes within roots, the
+// parts of files that are converted, such as the statements of a fragment
+// without the function they're wrapped in.
+func rootIDs(files []*ast.File, roots []ast.Node) map[ast.Node]int {
+	decls := make(map[ast.Node]bool)
+	for _, f := range files {
+		for _, n := range directiveTargets(f) {
+			decls[n] = true
+		}
+		inspect(f, func(n ast.Node) bool {
+			if d := declOf(n); d != nil {
+				decls[d] = true
+			}
+			return true
+		})
+	}
+
+	ids := make(map[ast.Node]int)
+	for _, root := range roots {
+		inspect(root, func(n ast.Node) bool {
+			if decls[n] {
+				ids[n] = len(ids) + 1
+			}
+			return true
+		})
+	}
+	return ids
+}
+
+// resolvePackage resolves the identifiers each file left unresolved against
+// the top level declarations of all the files. Files parsed without
+// resolving their identifiers have no scope, and are left as they are.
+func resolvePackage(files []*ast.File) {
+	scope := make(map[string]*ast.Object)
+	for _, f := range files {
+		if f.Scope == nil {
+			continue
+		}
+		for name, obj := range f.Scope.Objects {
+			if _, ok := scope[name]; !ok {
+				scope[name] = obj
+			}
+		}
+	}
+
+	for _, f := range files {
+		var unresolved []*ast.Ident
+		for _, id := range f.Unresolved {
+			if obj, ok := scope[id.Name]; ok {
+				id.Obj = obj
+			} else {
+				unresolved = append(unresolved, id)
+			}
+		}
+		f.Unresolved = unresolved
+	}
+}
+
+// declOf returns the node declaring the object n resolves to, if n is a
+// resolved identifier.
+func declOf(n ast.Node) ast.Node {
+	id, ok := n.(*ast.Ident)
+	if !ok || id.Obj == nil {
+		return nil
+	}
+	d, _ := id.Obj.Decl.(ast.Node)
+	return d
+}
+
+// addRefs sets the NodeID property of declaring nodes and the DeclRef
+// property of the identifiers pointing to them.
+func (c *converter) addRefs(n ast.Node) {
+	if id, ok := c.ids[n]; ok {
+		c.out.property("NodeID", id)
+	}
+	if d := declOf(n); d != nil {
+		if id, ok := c.ids[d]; ok {
+			c.out.property("DeclRef", id)
+		}
+	}
+}
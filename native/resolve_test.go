@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDeclRefs(t *testing.T) {
+	const content = `
+		package p
+
+		func f(a int) int {
+			b := a + len(x)
+			return b
+		}`
+
+	res, err := parse(&request{Content: content})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tt := []struct {
+		typ string
+		id  int
+	}{
+		{"FuncDecl", 1},
+		{"Field", 2},
+		{"AssignStmt", 3},
+	}
+	for _, tc := range tt {
+		if n := find(res, tc.typ, "NodeID", tc.id); n == nil {
+			t.Errorf("could not find %s with ID %d", tc.typ, tc.id)
+		}
+	}
+
+	var refs []string
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.InternalType == "Ident" {
+			ref := ""
+			if id, ok := n.Properties["DeclRef"]; ok {
+				ref = strconv.Itoa(id.(int))
+			}
+			refs = append(refs, n.Properties["Name"].(string)+":"+ref)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(res)
+
+	want := "p: f:1 a:2 int: int: b:3 a:2 len: x: b:3 int: int: len: x:"
+	if got := strings.Join(refs, " "); got != want {
+		t.Fatalf("expected references %q; got %q", want, got)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// serve reads the requests in r, one per line, and writes their responses
+// into w, processing up to workers requests at the same time.
+//
+// When ordered, responses are written in the same order as the requests, as
+// the SDK expects. Otherwise each one is written as soon as it's ready, so a
+// huge file doesn't hold back the ones behind it, and they must be matched
+// to their requests by ID.
+func serve(r io.Reader, w io.Writer, workers int, ordered bool) error {
+	if workers < 1 {
+		workers = 1
+	}
+	out := &responseWriter{w: bufio.NewWriter(w)}
+
+	// queue holds the responses being processed, in the order they're
+	// written. It's bounded too, so that a slow request doesn't make the
+	// ones behind it pile up in memory.
+	queue := make(chan chan *response, workers)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for res := range queue {
+			out.write(<-res)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+
+	in := bufio.NewReader(r)
+	for {
+		input, tooLong, err := readLine(in, maxLine())
+		if len(input) > 0 || tooLong {
+			if work := decode(input, tooLong); work != nil {
+				var res chan *response
+				if ordered {
+					res = make(chan *response, 1)
+					queue <- res
+				}
+
+				slots <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-slots }()
+
+					r, done := work()
+					if ordered {
+						res <- r
+					} else {
+						out.write(r)
+					}
+					// A request that timed out still holds its slot until
+					// it's really over.
+					if done != nil {
+						<-done
+					}
+				}()
+			}
+		}
+
+		if err != nil {
+			wg.Wait()
+			close(queue)
+			<-done
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// decode returns the work of answering the request in input, as respond
+// does, or nil if it can't be decoded. Requests that were too long to be
+// read at all get a response anyway, as they may be waited for, with the ID
+// found at the start of input, which holds only their first bytes.
+func decode(input []byte, tooLong bool) func() (*response, <-chan struct{}) {
+	if tooLong {
+		id := leadingID(input)
+		logrus.Warningf("request %q longer than %d bytes skipped", id, maxLine())
+		return func() (*response, <-chan struct{}) {
+			return failure(id, &requestError{
+				Code:    codeTooLarge,
+				Message: fmt.Sprintf("request is longer than the maximum of %d bytes", maxLine()),
+			}), nil
+		}
+	}
+
+	logrus.Infof("raw request: %s", input)
+	req := &request{}
+	if err := json.Unmarshal(input, req); err != nil {
+		logrus.Warningf("could not decode request: %v", err)
+		return nil
+	}
+	return func() (*response, <-chan struct{}) { return respond(req) }
+}
+
+// leadingID returns the ID of the request starting with prefix, if it's
+// found before the end of prefix, as it is when written first.
+func leadingID(prefix []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(prefix))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return ""
+	}
+	for {
+		key, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if k, ok := key.(string); ok && strings.EqualFold(k, "ID") {
+			id, _ := dec.Token()
+			s, _ := id.(string)
+			return s
+		}
+
+		// Skip the value, whole.
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return ""
+		}
+	}
+}
+
+// linePrefix is how much is kept of the lines that are too long.
+const linePrefix = 4 << 10
+
+// readLine reads the next line of r, without its line break. Lines longer
+// than max are skipped instead of being kept in memory, reporting tooLong,
+// but for their first linePrefix bytes.
+func readLine(r *bufio.Reader, max int) (line []byte, tooLong bool, err error) {
+	for {
+		var chunk []byte
+		chunk, err = r.ReadSlice('\n')
+		if !tooLong {
+			if max > 0 && len(line)+len(chunk) > max+1 {
+				if n := linePrefix - len(line); n > 0 {
+					line = append(line, chunk[:min(n, len(chunk))]...)
+				}
+				line, tooLong = append([]byte(nil), line[:min(len(line), linePrefix)]...), true
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return bytes.TrimRight(line, "\r\n"), tooLong, err
+		}
+	}
+}
+
+// responseWriter writes the responses of several goroutines, one at a time.
+type responseWriter struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+func (rw *responseWriter) write(res *response) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if err := writeResponse(rw.w, res); err != nil {
+		logrus.Errorf("could not encode response: %v", err)
+	}
+}
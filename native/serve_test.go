@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// serveRequests serves a request per content, with its index as ID, and
+// returns the responses in the order they were written.
+func serveRequests(t *testing.T, contents []string, workers int, ordered bool) []*response {
+	var in bytes.Buffer
+	for i, content := range contents {
+		b, err := json.Marshal(&request{ID: fmt.Sprint(i), Content: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.Write(append(b, '\n'))
+	}
+
+	var out bytes.Buffer
+	if err := serve(&in, &out, workers, ordered); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var responses []*response
+	s := bufio.NewScanner(&out)
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		var res struct {
+			ID     string
+			Status string
+		}
+		if err := json.Unmarshal(s.Bytes(), &res); err != nil {
+			t.Fatalf("could not decode response %q: %v", s.Text(), err)
+		}
+		responses = append(responses, &response{ID: res.ID, Status: res.Status})
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != len(contents) {
+		t.Fatalf("expected %d responses; got %d", len(contents), len(responses))
+	}
+	return responses
+}
+
+// serveContents returns a mix of small, big and broken files.
+func serveContents() []string {
+	big := "package p\n\nvar x = 1" + strings.Repeat(" + 1", 2000) + "\n"
+	var contents []string
+	for i := 0; i < 20; i++ {
+		switch i % 4 {
+		case 0:
+			contents = append(contents, big)
+		case 1:
+			contents = append(contents, "package p\n)")
+		default:
+			contents = append(contents, fmt.Sprintf("package p%d", i))
+		}
+	}
+	return contents
+}
+
+func TestServeOrdered(t *testing.T) {
+	contents := serveContents()
+	for i, res := range serveRequests(t, contents, 4, true) {
+		if res.ID != fmt.Sprint(i) {
+			t.Fatalf("expected response %d; got %s", i, res.ID)
+		}
+	}
+}
+
+func TestServeUnordered(t *testing.T) {
+	contents := serveContents()
+	var ids []string
+	for _, res := range serveRequests(t, contents, 4, false) {
+		ids = append(ids, res.ID)
+	}
+	sort.Strings(ids)
+
+	var want []string
+	for i := range contents {
+		want = append(want, fmt.Sprint(i))
+	}
+	sort.Strings(want)
+
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected responses %v; got %v", want, ids)
+	}
+}
+
+func TestServeWithoutID(t *testing.T) {
+	var out bytes.Buffer
+	if err := serve(strings.NewReader(`{"Content": "package p"}`+"\n"), &out, 1, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), `{"Status":"ok"`) {
+		t.Fatalf("expected no ID in the response; got %s", out.String())
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// sink receives the nodes of a tree in depth-first order: every node is
+// opened, given its properties, followed by its children and closed.
+//
+// Property values are a string, a bool or an int, so that they keep their
+// JSON type.
+type sink interface {
+	openNode(typ, name string, start, end position)
+	property(key string, value interface{})
+	closeNode()
+}
+
+// nodeSink builds the tree of nodes it receives.
+type nodeSink struct {
+	root  *node
+	stack []*node
+}
+
+func (s *nodeSink) openNode(typ, name string, start, end position) {
+	n := &node{
+		InternalType: typ,
+		InternalName: name,
+		StartOffset:  start.offset,
+		EndOffset:    end.offset,
+		StartLine:    start.line,
+		StartColumn:  start.column,
+		EndLine:      end.line,
+		EndColumn:    end.column,
+	}
+	if len(s.stack) == 0 {
+		s.root = n
+	} else {
+		parent := s.stack[len(s.stack)-1]
+		parent.Children = append(parent.Children, n)
+	}
+	s.stack = append(s.stack, n)
+}
+
+func (s *nodeSink) property(key string, value interface{}) {
+	n := s.stack[len(s.stack)-1]
+	if n.Properties == nil {
+		n.Properties = make(map[string]interface{})
+	}
+	n.Properties[key] = value
+}
+
+func (s *nodeSink) closeNode() {
+	s.stack = s.stack[:len(s.stack)-1]
+}
+
+// jsonSink writes the nodes it receives as JSON, exactly as encoding/json
+// would encode the tree of nodes, without building it.
+type jsonSink struct {
+	w   io.Writer
+	err error
+	buf []byte
+
+	// stack holds the nodes being written. Only the properties of the last
+	// one can be pending, as they're written as soon as a child is opened.
+	stack []jsonFrame
+	props []keyValue
+}
+
+type jsonFrame struct {
+	start, end position
+	children   bool
+}
+
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+func newJSONSink(w io.Writer) *jsonSink {
+	return &jsonSink{w: w, buf: make([]byte, 0, 64<<10)}
+}
+
+func (s *jsonSink) openNode(typ, name string, start, end position) {
+	if n := len(s.stack); n > 0 {
+		s.writeProps()
+		if parent := &s.stack[n-1]; parent.children {
+			s.buf = append(s.buf, ',')
+		} else {
+			parent.children = true
+			s.buf = append(s.buf, `,"Children":[`...)
+		}
+	}
+
+	s.buf = append(s.buf, `{"InternalType":`...)
+	s.buf = appendString(s.buf, typ)
+	if name != "" {
+		s.buf = append(s.buf, `,"InternalName":`...)
+		s.buf = appendString(s.buf, name)
+	}
+	s.stack = append(s.stack, jsonFrame{start: start, end: end})
+}
+
+func (s *jsonSink) property(key string, value interface{}) {
+	s.props = append(s.props, keyValue{key, value})
+}
+
+func (s *jsonSink) closeNode() {
+	s.writeProps()
+
+	f := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	if f.children {
+		s.buf = append(s.buf, ']')
+	}
+	s.appendInt(`,"StartOffset":`, int(f.start.offset))
+	s.appendInt(`,"EndOffset":`, int(f.end.offset))
+	s.appendInt(`,"StartLine":`, f.start.line)
+	s.appendInt(`,"StartColumn":`, f.start.column)
+	s.appendInt(`,"EndLine":`, f.end.line)
+	s.appendInt(`,"EndColumn":`, f.end.column)
+	s.buf = append(s.buf, '}')
+
+	if len(s.buf) > cap(s.buf)/2 {
+		s.flush()
+	}
+}
+
+// appendInt writes the field key with the value v, unless it's zero.
+func (s *jsonSink) appendInt(key string, v int) {
+	if v != 0 {
+		s.buf = append(s.buf, key...)
+		s.buf = strconv.AppendInt(s.buf, int64(v), 10)
+	}
+}
+
+// writeProps writes the pending properties sorted by key, like encoding/json
+// does with maps.
+func (s *jsonSink) writeProps() {
+	if len(s.props) == 0 {
+		return
+	}
+
+	props := s.props
+	for i := 1; i < len(props); i++ {
+		for j := i; j > 0 && props[j].key < props[j-1].key; j-- {
+			props[j], props[j-1] = props[j-1], props[j]
+		}
+	}
+
+	s.buf = append(s.buf, `,"Properties":{`...)
+	for i, p := range props {
+		if i > 0 {
+			s.buf = append(s.buf, ',')
+		}
+		s.buf = appendString(s.buf, p.key)
+		s.buf = append(s.buf, ':')
+		s.buf = appendValue(s.buf, p.value)
+	}
+	s.buf = append(s.buf, '}')
+	s.props = s.props[:0]
+}
+
+// flush writes the buffered output and returns the first error found while
+// writing, if any.
+func (s *jsonSink) flush() error {
+	if s.err == nil && len(s.buf) > 0 {
+		_, s.err = s.w.Write(s.buf)
+	}
+	s.buf = s.buf[:0]
+	return s.err
+}
+
+// appendValue appends the property value v to buf.
+func appendValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	}
+	panic(fmt.Sprintf("unsupported property value %T", v))
+}
+
+const hex = "0123456789abcdef"
+
+// appendString appends s to buf as a JSON string, escaping it the same way
+// encoding/json does, HTML characters included.
+func appendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid in JSON, but not in JavaScript.
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// structTag writes the keys of the struct tag lit, the Tag of a field, as
+// reflect.StructTag reads them: a StructTagKey node per key:"value" pair,
+// in a list named StructTag. Each has a StructTagText node named Key, with
+// the key as Value, one named Value, with the unquoted value as Value and,
+// as encoding/json and most packages split the value, the Name before the
+// first comma and a StructTagOption node per option after it.
+//
+// Tags that reflect.StructTag stops reading at a malformed pair get a
+// MalformedTag property set to true, and only the keys before it.
+func (c *converter) structTag(lit *ast.BasicLit, name string) {
+	if name != "Tag" || lit.Kind != token.STRING {
+		return
+	}
+	tag, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+
+	// The offsets in the tag are those in the literal unless it holds
+	// escapes, as "json:\"name\"" does, which only the literal spans.
+	start, end := lit.Pos(), lit.End()
+	span := func(from, to int) (position, position) {
+		if lit.Value[1:len(lit.Value)-1] != tag {
+			return c.position(start), c.position(end)
+		}
+		return c.position(start + 1 + token.Pos(from)), c.position(start + 1 + token.Pos(to))
+	}
+
+	keys, ok := structTagKeys(tag)
+	if !ok {
+		c.out.property("MalformedTag", true)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	c.out.openNode("ListOfStructTagKey", "StructTag", position{}, position{})
+	for _, k := range keys {
+		from, to := span(k.start, k.end)
+		c.out.openNode("StructTagKey", "", from, to)
+
+		// The options are only found where the value is written unescaped.
+		exact := k.raw == k.value
+		parts := strings.Split(k.value, ",")
+		if parts[0] != "" {
+			c.out.property("Name", parts[0])
+		}
+
+		from, to = span(k.start, k.start+len(k.key))
+		c.out.openNode("StructTagText", "Key", from, to)
+		c.out.property("Value", k.key)
+		c.out.closeNode()
+		from, to = span(k.valueStart-1, k.end)
+		c.out.openNode("StructTagText", "Value", from, to)
+		c.out.property("Value", k.value)
+		c.out.closeNode()
+
+		if len(parts) > 1 {
+			c.out.openNode("ListOfStructTagOption", "Options", position{}, position{})
+			at := k.valueStart + len(parts[0])
+			for _, opt := range parts[1:] {
+				at++
+				from, to := span(k.start, k.end)
+				if exact {
+					from, to = span(at, at+len(opt))
+				}
+				c.out.openNode("StructTagOption", "", from, to)
+				c.out.property("Value", opt)
+				c.out.closeNode()
+				at += len(opt)
+			}
+			c.out.closeNode()
+		}
+		c.out.closeNode()
+	}
+	c.out.closeNode()
+}
+
+// structTagKey is a key:"value" pair of a struct tag, at offsets start to
+// end of it. raw is the value as it's written, without its quotes, which
+// starts at valueStart.
+type structTagKey struct {
+	key, value, raw        string
+	start, end, valueStart int
+}
+
+// structTagKeys returns the pairs of the struct tag, parsed as
+// reflect.StructTag.Lookup does, and false if they end at a malformed pair.
+func structTagKeys(tag string) ([]structTagKey, bool) {
+	var keys []structTagKey
+	for offset := 0; ; {
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag, offset = tag[i:], offset+i
+		if tag == "" {
+			return keys, true
+		}
+
+		// The key is a run of non-control characters other than space,
+		// quote and colon.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return keys, false
+		}
+		k := structTagKey{key: tag[:i], start: offset}
+
+		// The value is a quoted string.
+		j := i + 2
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			return keys, false
+		}
+		value, err := strconv.Unquote(tag[i+1 : j+1])
+		if err != nil {
+			return keys, false
+		}
+		k.value, k.raw = value, tag[i+2:j]
+		k.valueStart, k.end = offset+i+2, offset+j+1
+		keys = append(keys, k)
+		tag, offset = tag[j+1:], offset+j+1
+	}
+}
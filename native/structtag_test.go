@@ -0,0 +1,171 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testStructTags = "package p\n\ntype T struct {\n" +
+	"\tA int `json:\"a,omitempty,string\" db:\"id\"`\n" +
+	"\tB int \"json:\\\"b\\\"\"\n" +
+	"\tC int `json:\",omitempty\" bad`\n" +
+	"\tD int `xml:\"d\"`\n" +
+	"}\n"
+
+func TestStructTags(t *testing.T) {
+	res, err := parse(&request{Content: testStructTags})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tt := []struct {
+		key, value, name string
+		options          []string
+	}{
+		{"json", "a,omitempty,string", "a", []string{"omitempty", "string"}},
+		{"db", "id", "id", nil},
+		{"json", "b", "b", nil},
+		{"json", ",omitempty", "", []string{"omitempty"}},
+		{"xml", "d", "d", nil},
+	}
+	var keys []*node
+	for _, lit := range findAll(res, "BasicLit") {
+		if lit.InternalName == "Tag" {
+			keys = append(keys, lit.Children[0].Children...)
+		}
+	}
+	if len(keys) != len(tt) {
+		t.Fatalf("expected %d keys; got %d", len(tt), len(keys))
+	}
+	for i, tc := range tt {
+		k := keys[i]
+		name, _ := k.Properties["Name"].(string)
+		key, value := child(k, "Key"), child(k, "Value")
+		if key == nil || value == nil {
+			t.Fatalf("expected a key and a value; got %+v", k.Children)
+		}
+		if key.Properties["Value"] != tc.key || value.Properties["Value"] != tc.value || name != tc.name {
+			t.Errorf("expected %s:%q named %q; got %v:%v named %q", tc.key, tc.value, tc.name, key.Properties, value.Properties, name)
+		}
+
+		var options []string
+		if list := child(k, "Options"); list != nil {
+			for _, o := range list.Children {
+				options = append(options, o.Properties["Value"].(string))
+			}
+		}
+		if strings.Join(options, "|") != strings.Join(tc.options, "|") {
+			t.Errorf("expected the options %q; got %q", tc.options, options)
+		}
+	}
+}
+
+func TestStructTagOffsets(t *testing.T) {
+	res, err := parse(&request{Content: testStructTags})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k := find(res, "StructTagText", "Value", "db")
+	want := strings.Index(testStructTags, `db:"id"`)
+	if int(k.StartOffset) != want || int(k.EndOffset) != want+2 {
+		t.Errorf("expected db at %d-%d; got %d-%d", want, want+2, k.StartOffset, k.EndOffset)
+	}
+	if v := find(res, "StructTagText", "Value", "id"); int(v.StartOffset) != want+3 || int(v.EndOffset) != want+7 || v.InternalName != "Value" {
+		t.Errorf("expected the value at %d-%d; got %+v", want+3, want+7, v)
+	}
+	if p := find(res, "StructTagKey", "Name", "id"); int(p.StartOffset) != want || int(p.EndOffset) != want+7 {
+		t.Errorf("expected the pair at %d-%d; got %d-%d", want, want+7, p.StartOffset, p.EndOffset)
+	}
+	o := find(res, "StructTagOption", "Value", "string")
+	if want := strings.Index(testStructTags, "string"); int(o.StartOffset) != want || o.StartColumn != 27 {
+		t.Errorf("expected the option at offset %d; got %+v", want, o)
+	}
+
+	// The tag of B holds escapes.
+	k = find(res, "StructTagText", "Value", "b")
+	if want := strings.Index(testStructTags, `"json`); int(k.StartOffset) != want || int(k.EndOffset) != want+len(`"json:\"b\""`) {
+		t.Errorf("expected the value to span the tag; got %d-%d", k.StartOffset, k.EndOffset)
+	}
+}
+
+func TestMalformedStructTags(t *testing.T) {
+	tt := []struct {
+		tag  string
+		keys int
+		ok   bool
+	}{
+		{``, 0, true},
+		{`  a:"1"  b:"2" `, 2, true},
+		{`a:"1"b:"2"`, 2, true},
+		{`a:"1" bad`, 1, false},
+		{`a: "1"`, 0, false},
+		{`:"1"`, 0, false},
+		{`a:"1`, 0, false},
+		{`a:"\z"`, 0, false},
+	}
+	for _, tc := range tt {
+		keys, ok := structTagKeys(tc.tag)
+		if len(keys) != tc.keys || ok != tc.ok {
+			t.Errorf("structTagKeys(%q) = %d keys, %v; want %d, %v", tc.tag, len(keys), ok, tc.keys, tc.ok)
+		}
+	}
+
+	res, err := parse(&request{Content: testStructTags})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, lit := range findAll(res, "BasicLit") {
+		malformed := strings.Contains(lit.Properties["Value"].(string), "bad")
+		if _, flagged := lit.Properties["MalformedTag"]; flagged != malformed {
+			t.Errorf("unexpected MalformedTag for %s", lit.Properties["Value"])
+		}
+	}
+}
+
+func TestStructTagsMatchReflection(t *testing.T) {
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, "", testStructTags, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf := &corpusFile{fs: fs, f: f, ids: declIDs(f)}
+
+	s := &nodeSink{}
+	cf.convert(s)
+	if want := cf.reflectTree(); !cmp.Equal(want, s.root) {
+		t.Errorf("different trees: %s", cmp.Diff(want, s.root))
+	}
+}
+
+func TestPrintStructTags(t *testing.T) {
+	if got := printTree(t, testStructTags); got != testStructTags {
+		t.Errorf("different code:\n%s", got)
+	}
+}
+
+// child returns the child of n named name, or nil.
+func child(n *node, name string) *node {
+	for _, c := range n.Children {
+		if c.InternalName == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// findAll returns the nodes of type typ under n, in order.
+func findAll(n *node, typ string) []*node {
+	var nodes []*node
+	if n.InternalType == typ {
+		nodes = append(nodes, n)
+	}
+	for _, c := range n.Children {
+		nodes = append(nodes, findAll(c, typ)...)
+	}
+	return nodes
+}
@@ -0,0 +1,14 @@
+// Package p is printed back from the UAST of the driver.
+package p
+
+import "fmt"
+
+// T has a tagged field.
+type T struct {
+	Name string `json:"name,omitempty"` //nolint:unused
+}
+
+//go:noinline
+func (t T) String() string {
+	return fmt.Sprintf("%s: %d", t.Name, 0x1F+'a')
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// What a response holds, chosen by the Output of a request.
+const (
+	astOutput    = "ast"
+	tokensOutput = "tokens"
+	bothOutput   = "both"
+)
+
+// lexToken is a token of a file, as go/scanner returns it.
+type lexToken struct {
+	// Kind is the token as go/token writes it: IDENT, INT, COMMENT, func, +...
+	Kind    string
+	Literal string `json:",omitempty"`
+	// Inserted is set on the semicolons added by the scanner at the end of
+	// lines and files, which take no space.
+	Inserted    bool `json:",omitempty"`
+	StartOffset int
+	EndOffset   int
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+}
+
+// scanTokens returns the tokens of the content of req, comments included,
+// and the errors found while scanning them.
+func scanTokens(req *request) ([]*lexToken, error) {
+	src := req.Content
+	fs := token.NewFileSet()
+	tf := fs.AddFile(req.Filename, -1, len(src))
+
+	var (
+		s    scanner.Scanner
+		errs scanner.ErrorList
+	)
+	s.Init(tf, []byte(src), errs.Add, scanner.ScanComments)
+
+	type scanned struct {
+		pos token.Pos
+		tok token.Token
+		lit string
+	}
+	var all []scanned
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		all = append(all, scanned{pos, tok, lit})
+	}
+
+	// The lines of the file are only known once it's all scanned.
+	l := newLocator(tf)
+	l.columns = columnTable(src, req.ColumnMode)
+
+	toks := make([]*lexToken, 0, len(all))
+	for _, t := range all {
+		start := int(t.pos - l.base)
+		lt := &lexToken{Kind: t.tok.String(), Literal: t.lit}
+		if t.tok == token.SEMICOLON && t.lit == "\n" {
+			lt.Literal, lt.Inserted = "", true
+		}
+		if t.tok.IsOperator() || t.tok.IsKeyword() || lt.Inserted {
+			// Only the ones with a value are worth repeating.
+			lt.Literal = ""
+		}
+
+		end := t.pos + token.Pos(tokenLen(src[start:], t.tok, t.lit, lt.Inserted))
+		sp, ep := l.position(t.pos), l.position(end)
+		lt.StartOffset, lt.EndOffset = int(sp.offset), int(ep.offset)
+		lt.StartLine, lt.StartColumn = sp.line, sp.column
+		lt.EndLine, lt.EndColumn = ep.line, ep.column
+		toks = append(toks, lt)
+	}
+	return toks, errs.Err()
+}
+
+// tokenLen returns the length in bytes of the token tok, with the literal
+// lit, at the start of src.
+func tokenLen(src string, tok token.Token, lit string, inserted bool) int {
+	switch {
+	case inserted:
+		return 0
+	case tok == token.COMMENT && strings.HasPrefix(src, "/*"),
+		tok == token.STRING && strings.HasPrefix(src, "`"):
+		// The scanner drops the carriage returns of these literals, so
+		// their end is looked for in the source.
+		closing := "*/"
+		if tok == token.STRING {
+			closing = "`"
+		}
+		if i := strings.Index(src[len(closing):], closing); i >= 0 {
+			return i + 2*len(closing)
+		}
+		return len(src)
+	case tok == token.COMMENT:
+		if i := strings.IndexByte(src, '\n'); i >= 0 {
+			// Line comments keep a carriage return ending the line.
+			return len(strings.TrimSuffix(src[:i], "\r"))
+		}
+		return len(src)
+	case lit != "":
+		return len(lit)
+	}
+	return len(tok.String())
+}
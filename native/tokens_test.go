@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanTokens(t *testing.T) {
+	const content = "package p // p\n\nvar s = `a\r\nb` /* c\r\n */ + x\n"
+
+	toks, err := scanTokens(&request{Content: content})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type tok struct {
+		Kind, Literal, Text string
+		Inserted            bool
+	}
+	var got []tok
+	for _, lt := range toks {
+		got = append(got, tok{lt.Kind, lt.Literal, content[lt.StartOffset:lt.EndOffset], lt.Inserted})
+	}
+
+	want := []tok{
+		{Kind: "package", Text: "package"},
+		{Kind: "IDENT", Literal: "p", Text: "p"},
+		{Kind: "COMMENT", Literal: "// p", Text: "// p"},
+		{Kind: ";", Inserted: true},
+		{Kind: "var", Text: "var"},
+		{Kind: "IDENT", Literal: "s", Text: "s"},
+		{Kind: "=", Text: "="},
+		{Kind: "STRING", Literal: "`a\nb`", Text: "`a\r\nb`"},
+		{Kind: "COMMENT", Literal: "/* c\n */", Text: "/* c\r\n */"},
+		// A comment spanning lines ends them, as a newline would.
+		{Kind: ";", Inserted: true},
+		{Kind: "+", Text: "+"},
+		{Kind: "IDENT", Literal: "x", Text: "x"},
+		{Kind: ";", Inserted: true},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("different tokens: %s", cmp.Diff(want, got))
+	}
+
+	if c := toks[8]; c.StartLine != 4 || c.StartColumn != 4 || c.EndLine != 5 || c.EndColumn != 4 {
+		t.Fatalf("expected comment at 4:4-5:4; got %d:%d-%d:%d", c.StartLine, c.StartColumn, c.EndLine, c.EndColumn)
+	}
+}
+
+func TestTokensOutput(t *testing.T) {
+	res := handle(&request{Content: "package p\n\nvar s = \"s", Output: "tokens"})
+	if res.Status != "error" {
+		t.Fatalf("expected status error; got %s", res.Status)
+	}
+	if res.AST.Root != nil {
+		t.Fatalf("expected no tree; got %s", res.AST.Root)
+	}
+	if len(res.AST.Tokens) != 8 {
+		t.Fatalf("expected 8 tokens; got %d", len(res.AST.Tokens))
+	}
+	if len(res.AST.Errors) != 1 || res.AST.Errors[0].Message != "string literal not terminated" {
+		t.Fatalf("expected the scanner error; got %v", res.Errors)
+	}
+
+	res = handle(&request{Content: "package p", Output: "both"})
+	if res.Status != "ok" || res.AST.Root == nil || len(res.AST.Tokens) != 3 {
+		t.Fatalf("expected tree and tokens; got %s with %d tokens", res.Status, len(res.AST.Tokens))
+	}
+
+	res = handle(&request{Content: "package p"})
+	if res.AST.Tokens != nil {
+		t.Fatalf("expected no tokens; got %d", len(res.AST.Tokens))
+	}
+}
+
+func TestInvalidOutput(t *testing.T) {
+	for _, req := range []*request{
+		{Content: "package p", Output: "tree"},
+		{Files: []*sourceFile{{Name: "a.go", Content: "package p"}}, Output: "tokens"},
+	} {
+		if res := handle(req); res.Status != "fatal" || res.Code != codeInvalidRequest {
+			t.Errorf("expected fatal %s; got %s %s", codeInvalidRequest, res.Status, res.Code)
+		}
+	}
+}
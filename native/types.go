@@ -0,0 +1,117 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sync"
+)
+
+// imports is the importer of every type check. It keeps the packages it
+// imports from source, so that each dependency is only checked once for all
+// the requests, whichever worker handles them.
+var imports = &sharedImporter{
+	imp: importer.ForCompiler(token.NewFileSet(), "source", nil).(types.ImporterFrom),
+}
+
+// sharedImporter guards an importer, which isn't safe for concurrent use, so
+// that several type checks can share it.
+type sharedImporter struct {
+	mu  sync.Mutex
+	imp types.ImporterFrom
+}
+
+func (si *sharedImporter) Import(path string) (*types.Package, error) {
+	return si.ImportFrom(path, "", 0)
+}
+
+func (si *sharedImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.imp.ImportFrom(path, dir, mode)
+}
+
+// typeCheck runs go/types over the files of a package, importing its
+// dependencies from source through the shared imports. Type errors are not
+// reported: the returned info holds whatever could be resolved despite them.
+func typeCheck(fs *token.FileSet, files []*ast.File) (*types.Package, *types.Info) {
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: imports,
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fs, files, info)
+	return pkg, info
+}
+
+// addTypes sets the ResolvedType, ObjectKind and ConstantValue properties of
+// n, as far as they are known.
+func (c *converter) addTypes(n ast.Node) {
+	expr, ok := n.(ast.Expr)
+	if !ok {
+		return
+	}
+
+	var obj types.Object
+	if id, ok := n.(*ast.Ident); ok {
+		obj = c.info.ObjectOf(id)
+	}
+
+	var value string
+	if tv, ok := c.info.Types[expr]; ok {
+		c.setType(tv.Type)
+		if tv.Value != nil {
+			value = tv.Value.ExactString()
+		}
+	} else if obj != nil {
+		c.setType(obj.Type())
+	}
+
+	if obj != nil {
+		c.out.property("ObjectKind", objectKind(obj))
+		if k, ok := obj.(*types.Const); ok {
+			value = k.Val().ExactString()
+		}
+	}
+	if value != "" {
+		c.out.property("ConstantValue", value)
+	}
+}
+
+// setType sets the ResolvedType property to t, qualified relative to the
+// package being checked. Package names and labels have no type.
+func (c *converter) setType(t types.Type) {
+	if t == nil || t == types.Typ[types.Invalid] {
+		return
+	}
+	c.out.property("ResolvedType", types.TypeString(t, types.RelativeTo(c.pkg)))
+}
+
+// objectKind returns the kind of object obj is: var, func, const, type,
+// pkgname, label, builtin or nil.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Var:
+		return "var"
+	case *types.Func:
+		return "func"
+	case *types.Const:
+		return "const"
+	case *types.TypeName:
+		return "type"
+	case *types.PkgName:
+		return "pkgname"
+	case *types.Label:
+		return "label"
+	case *types.Builtin:
+		return "builtin"
+	case *types.Nil:
+		return "nil"
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// find returns the first node in n, in depth-first order, with the given
+// type and property, or just the given type if key is empty.
+func find(n *node, typ, key string, value interface{}) *node {
+	if n == nil {
+		return nil
+	}
+	if n.InternalType == typ && (key == "" || n.Properties[key] == value) {
+		return n
+	}
+	for _, c := range n.Children {
+		if f := find(c, typ, key, value); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestTypeCheck(t *testing.T) {
+	const content = `
+		package p
+
+		import "errors"
+
+		type T struct{ err error }
+
+		const c = 40 + 2
+
+		func f() {
+		loop:
+			for {
+				t := T{err: errors.New("boom")}
+				_ = t
+				break loop
+			}
+		}`
+
+	res, err := parse(&request{Content: content, TypeCheck: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tt := []struct {
+		typ, key string
+		value    interface{}
+		props    map[string]interface{}
+	}{
+		{"Ident", "Name", "T", props("ObjectKind", "type", "ResolvedType", "T")},
+		{"Ident", "Name", "errors", props("ObjectKind", "pkgname")},
+		{"Ident", "Name", "New", props("ObjectKind", "func", "ResolvedType", "func(text string) error")},
+		{"Ident", "Name", "c", props("ObjectKind", "const", "ResolvedType", "untyped int", "ConstantValue", "42")},
+		{"BinaryExpr", "Op", "+", props("ResolvedType", "untyped int", "ConstantValue", "42")},
+		{"BasicLit", "Value", `"boom"`, props("ResolvedType", "string", "ConstantValue", `"boom"`)},
+		{"Ident", "Name", "loop", props("ObjectKind", "label")},
+		{"Ident", "Name", "t", props("ObjectKind", "var", "ResolvedType", "T")},
+		{"CompositeLit", "Incomplete", false, props("ResolvedType", "T")},
+	}
+
+	for _, tc := range tt {
+		n := find(res, tc.typ, tc.key, tc.value)
+		if n == nil {
+			t.Errorf("could not find %s with %s %v", tc.typ, tc.key, tc.value)
+			continue
+		}
+		if got := typeProps(n); !cmp.Equal(tc.props, got) {
+			t.Errorf("different properties for %s %v: %s", tc.typ, tc.value, cmp.Diff(tc.props, got))
+		}
+	}
+}
+
+func TestNoTypeCheck(t *testing.T) {
+	res, err := parse(&request{Content: "package p\n\nconst c = 1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := typeProps(find(res, "Ident", "Name", "c")); len(got) != 0 {
+		t.Fatalf("unexpected type information: %v", got)
+	}
+}
+
+func TestTypeCheckConcurrently(t *testing.T) {
+	const content = "package p\n\nimport \"strings\"\n\nvar b strings.Builder"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := parse(&request{Content: content, TypeCheck: true})
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			if got := find(res, "Ident", "Name", "b").Properties["ResolvedType"]; got != "strings.Builder" {
+				t.Errorf("expected strings.Builder; got %v", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The package is only imported once.
+	a, _ := imports.Import("strings")
+	b, _ := imports.Import("strings")
+	if a == nil || a != b {
+		t.Errorf("expected the same package; got %p and %p", a, b)
+	}
+}
+
+// typeProps returns the properties of n added by type checking.
+func typeProps(n *node) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, k := range []string{"ResolvedType", "ObjectKind", "ConstantValue"} {
+		if v, ok := n.Properties[k]; ok {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func TestTypeCheckGenerics(t *testing.T) {
+	const content = `
+		package p
+
+		type Number interface{ ~int | ~float64 }
+
+		func Sum[T Number, K comparable](m map[K]T) T {
+			var s T
+			for _, v := range m {
+				s += v
+			}
+			return s
+		}
+
+		var total = Sum[float64, any]`
+
+	res, err := parse(&request{Content: content, TypeCheck: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tt := []struct {
+		typ, key, value string
+		props           map[string]interface{}
+	}{
+		{"Ident", "Name", "T", props("ObjectKind", "type", "ResolvedType", "T")},
+		{"Ident", "Name", "comparable", props("ObjectKind", "type", "ResolvedType", "comparable")},
+		{"Ident", "Name", "any", props("ObjectKind", "type", "ResolvedType", "any")},
+		{"UnaryExpr", "Op", "~", props("ResolvedType", "~int")},
+		{"IndexListExpr", "", "", props("ResolvedType", "func(m map[any]float64) float64")},
+	}
+
+	for _, tc := range tt {
+		n := find(res, tc.typ, tc.key, tc.value)
+		if n == nil {
+			t.Errorf("could not find %s with %s %s", tc.typ, tc.key, tc.value)
+			continue
+		}
+		if got := typeProps(n); !cmp.Equal(tc.props, got) {
+			t.Errorf("different properties for %s %s: %s", tc.typ, tc.value, cmp.Diff(tc.props, got))
+		}
+	}
+}